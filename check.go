@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/PaulPowershell/Kram/pkg/kram"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================
+// CHECK (kram check [namespace] --policy policy.yaml)
+// ============================================================
+
+// Exit statuses of kram check
+const (
+	// exitPolicyFailed is returned when a rule of severity error is broken
+	exitPolicyFailed = 1
+	// exitIncomplete is returned when no error rule is broken but some metrics could not be collected
+	exitIncomplete = 2
+)
+
+func newCheckCmd(cfg *Config) *cobra.Command {
+	var policyPath string
+
+	checkCmd := &cobra.Command{
+		Use:   "check [namespace]",
+		Short: "Check requests, limits and usage against a policy file",
+		Long: "Check evaluates the rules of a YAML policy (required requests and limits, maximum quantities, minimum usage/request ratio) " +
+			"on the collected containers, pods, workloads or namespaces, prints the violations grouped by severity and exits with status 1 when a rule of severity error is broken, " +
+			"or 2 when none is but the collection was incomplete.",
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var policy *kram.Policy
+			// Workload rules need pods resolved up to their top-level controller
+			cfg.ResolveOwners = true
+			cluster, errorsList := collectForCommand(cfg, args, false, func() error {
+				if policyPath == "" {
+					return ErrInvalidCheckOptions
+				}
+				var err error
+				policy, err = kram.ReadPolicy(policyPath)
+				return err
+			})
+
+			violations := policy.Check(cluster)
+			renderReport(cfg.OutputFormat, checkReport(violations, cfg.Namespace), errorsList)
+
+			if !renderers[cfg.OutputFormat].embedsErrors {
+				printErrors(errorsList)
+			}
+
+			// Documents carry the violations themselves: only the exit status tells the outcome
+			errorCount := countSeverity(violations, kram.SeverityError)
+			machineReadable := renderers[cfg.OutputFormat].machineReadable
+			if errorCount > 0 {
+				if !machineReadable {
+					pterm.Error.Printf("Policy check failed: %d error(s), %d warning(s)\n", errorCount, len(violations)-errorCount)
+				}
+				os.Exit(exitPolicyFailed)
+			}
+			// A check on partial data cannot pass
+			if len(errorsList) > 0 {
+				if !machineReadable {
+					pterm.Error.Printf("Policy check incomplete: %d collection error(s), %d warning(s)\n", len(errorsList), len(violations))
+				}
+				os.Exit(exitIncomplete)
+			}
+			if !machineReadable {
+				pterm.Success.Printf("Policy check passed: %d warning(s)\n", len(violations))
+			}
+		},
+	}
+
+	addCollectionFlags(checkCmd, cfg, "Check every namespace")
+	addSamplingFlags(checkCmd, cfg, "Sample usage over this window and check the average usage (e.g. 1h)")
+	checkCmd.Flags().StringVar(&policyPath, "policy", "", "YAML file with the rules to check")
+
+	return checkCmd
+}
+
+// countSeverity counts the violations of the given severity
+func countSeverity(violations []kram.Violation, severity kram.Severity) int {
+	n := 0
+	for _, v := range violations {
+		if v.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+func checkReport(violations []kram.Violation, namespace string) *report {
+	errorCount := countSeverity(violations, kram.SeverityError)
+	summary := [][]string{
+		{"Errors", "Warnings"},
+		{pterm.Sprint(errorCount), pterm.Sprint(len(violations) - errorCount)},
+	}
+	sections := []reportSection{{Title: "Policy check", Data: summary}}
+
+	doc := newJSONDocument("check", namespace)
+	header := []string{"Rule", "Scope", "Namespace", "Subject", "Violation"}
+	tables := map[kram.Severity][][]string{
+		kram.SeverityError:   {header},
+		kram.SeverityWarning: {header},
+	}
+	for _, v := range violations {
+		tables[v.Severity] = append(tables[v.Severity], []string{v.Rule, string(v.Scope), v.Namespace, v.Name, v.Message})
+		doc.Violations = append(doc.Violations, jsonViolation{
+			Rule:      v.Rule,
+			Severity:  string(v.Severity),
+			Scope:     string(v.Scope),
+			Namespace: v.Namespace,
+			Name:      v.Name,
+			Message:   v.Message,
+		})
+	}
+	if len(tables[kram.SeverityError]) > 1 {
+		sections = append(sections, reportSection{Title: fmt.Sprintf("Errors (%d)", errorCount), Data: tables[kram.SeverityError]})
+	}
+	if len(tables[kram.SeverityWarning]) > 1 {
+		sections = append(sections, reportSection{Title: fmt.Sprintf("Warnings (%d)", len(violations)-errorCount), Data: tables[kram.SeverityWarning]})
+	}
+
+	name := "kram-check"
+	if namespace != "" {
+		name = fmt.Sprintf("kram-check-%s", namespace)
+	}
+	return &report{Name: name, Sections: sections, Document: doc}
+}
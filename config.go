@@ -1,54 +1,186 @@
 package main
 
 import (
+	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
+	"time"
 
-	"k8s.io/client-go/util/homedir"
+	"github.com/PaulPowershell/Kram/pkg/kram"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 )
 
 // Config centralizes all application configuration
 type Config struct {
-	Kubeconfig   string
+	// KubeFlags holds the kubectl connection flags (--kubeconfig, --context, -n, --as, ...)
+	KubeFlags    *genericclioptions.ConfigFlags
 	OutputFormat string
 	ShowNode     bool
 	ShowCPUOnly  bool
 	ShowRAMOnly  bool
-	Namespace    string
+	// ShowEfficiency adds usage/request, request/limit and waste columns
+	ShowEfficiency bool
+	// ShowWorkloads sums the pods per top-level controller (Deployment, StatefulSet, CronJob, ...)
+	ShowWorkloads bool
+	// ResolveOwners follows ReplicaSets and Jobs up to their controller, set by the views reporting per workload
+	ResolveOwners bool
+	// SortBy, Reverse and Top order the rows of the views and keep the first ones
+	SortBy  string
+	Reverse bool
+	Top     int
+	// GroupBy replaces the namespaces, or the nodes of the node views, by label values (e.g. label:team)
+	GroupBy       string
+	Namespace     string
+	AllNamespaces bool
+	LabelSelector string
+	// FieldSelector restricts pods by field (e.g. spec.nodeName=node-3), NamespaceSelector the namespaces by label
+	FieldSelector     string
+	NamespaceSelector string
+	FromSnapshot      string
+	// Duration and Interval define the sampling window; a single reading when Duration is 0
+	Duration time.Duration
+	Interval time.Duration
+	// Watch is the refresh interval of the live dashboard; 0 renders once
+	Watch time.Duration
+	// Contexts and KubeconfigGlob select the clusters of a multi-cluster report
+	Contexts       []string
+	KubeconfigGlob string
 }
 
-// NewConfig creates and validates a new Config instance
+// NewConfig creates and validates a new Config instance.
+// The kubeconfig is left empty so $KUBECONFIG and ~/.kube/config are resolved by the client-go loading rules.
 func NewConfig() *Config {
-	home := ""
-	if h := homedir.HomeDir(); h != "" {
-		home = filepath.Join(h, ".kube", "config")
-	}
-
 	return &Config{
-		Kubeconfig:   home,
+		KubeFlags:    genericclioptions.NewConfigFlags(true),
 		OutputFormat: "table",
 		ShowNode:     false,
 		ShowCPUOnly:  false,
 		ShowRAMOnly:  false,
 		Namespace:    "",
+		Interval:     15 * time.Second,
+	}
+}
+
+// ResolveNamespace picks the namespace from the positional argument (kept for compatibility) or -n
+func (c *Config) ResolveNamespace(args []string) error {
+	flagNamespace := ""
+	if c.KubeFlags.Namespace != nil {
+		flagNamespace = *c.KubeFlags.Namespace
 	}
+
+	switch {
+	case len(args) > 0 && flagNamespace != "" && args[0] != flagNamespace:
+		return ErrNamespaceConflict
+	case len(args) > 0:
+		c.Namespace = args[0]
+	default:
+		c.Namespace = flagNamespace
+	}
+	return nil
+}
+
+// Grouping returns the parsed --group-by, the zero GroupBy when unset or invalid
+func (c *Config) Grouping() kram.GroupBy {
+	by, _ := kram.ParseGroupBy(c.GroupBy)
+	return by
+}
+
+// Ordering returns the row order of the views, the name order when --sort-by is unset or invalid
+func (c *Config) Ordering() kram.Order {
+	key, _ := kram.ParseSortKey(c.SortBy)
+	return kram.Order{Key: key, Reverse: c.Reverse, Top: c.Top}
+}
+
+// MultiCluster reports whether several clusters are collected into one report
+func (c *Config) MultiCluster() bool {
+	return len(c.Contexts) > 0 || c.KubeconfigGlob != ""
 }
 
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
-	if c.OutputFormat != "table" && c.OutputFormat != "html" {
-		return ErrInvalidOutput
+	if _, ok := renderers[c.OutputFormat]; !ok {
+		return invalidOutputError()
 	}
 
 	if (c.ShowCPUOnly || c.ShowRAMOnly) && !c.ShowNode {
 		return ErrFlagOnlyWithNode
 	}
 
-	if c.Kubeconfig != "" {
-		if _, err := os.Stat(c.Kubeconfig); err != nil {
+	if c.MultiCluster() && (c.ShowNode || c.Namespace != "" || c.FromSnapshot != "") {
+		return ErrMultiClusterView
+	}
+
+	if c.Duration != 0 && (c.Duration < 0 || c.Interval <= 0 || c.Duration < c.Interval) {
+		return ErrInvalidSamplingWindow
+	}
+
+	if c.Duration != 0 && (c.MultiCluster() || c.FromSnapshot != "") {
+		return ErrSamplingWindowSource
+	}
+
+	if c.Watch < 0 {
+		return ErrInvalidWatchInterval
+	}
+
+	if c.Watch > 0 && (c.OutputFormat != "table" || c.Duration != 0 || c.MultiCluster() || c.FromSnapshot != "") {
+		return ErrWatchConflict
+	}
+
+	if _, err := kram.ParseGroupBy(c.GroupBy); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidGroupBy, err)
+	}
+
+	if c.GroupBy != "" && c.Namespace != "" && !c.ShowNode {
+		return ErrGroupByView
+	}
+
+	if _, err := kram.ParseSortKey(c.SortBy); err != nil || c.Top < 0 {
+		return invalidOrderError()
+	}
+
+	if c.ShowWorkloads && (c.ShowNode || c.GroupBy != "" || c.MultiCluster()) {
+		return ErrWorkloadsView
+	}
+
+	if _, err := fields.ParseSelector(c.FieldSelector); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSelector, err)
+	}
+
+	if _, err := labels.Parse(c.NamespaceSelector); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSelector, err)
+	}
+
+	if c.NamespaceSelector != "" && c.Namespace != "" {
+		return ErrNamespaceSelectorConflict
+	}
+
+	if c.AllNamespaces && c.Namespace != "" {
+		return ErrAllNamespacesConflict
+	}
+
+	// The kubeconfig is not used when replaying a snapshot
+	if kubeconfig := c.KubeFlags.KubeConfig; kubeconfig != nil && *kubeconfig != "" && c.FromSnapshot == "" {
+		if _, err := os.Stat(*kubeconfig); err != nil {
 			return ErrKubeconfigNotFound
 		}
 	}
 
 	return nil
 }
+
+// sortKeyNames returns the accepted --sort-by values
+func sortKeyNames() []string {
+	keys := make([]string, len(kram.SortKeys))
+	for i, key := range kram.SortKeys {
+		keys[i] = string(key)
+	}
+	return keys
+}
+
+// invalidOrderError lists the sort keys in the error message
+func invalidOrderError() error {
+	return fmt.Errorf("%w. Use one of: %s", ErrInvalidOrder, strings.Join(sortKeyNames(), ", "))
+}
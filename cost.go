@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/PaulPowershell/Kram/pkg/kram"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================
+// COST (kram cost [namespace] --pricing pricing.yaml)
+// ============================================================
+
+func newCostCmd(cfg *Config) *cobra.Command {
+	var pricingPath, basis, groupBy string
+
+	costCmd := &cobra.Command{
+		Use:   "cost [namespace]",
+		Short: "Estimate the hourly and monthly cost per namespace, team and node",
+		Long: "Cost prices the resources charged to every pod (request, usage or the highest of both) at the rates of its node " +
+			"from a pricing file, and reports the cost per namespace, per --group-by value (the team label by default) and per node with the idle allocatable capacity.",
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var pricing *kram.Pricing
+			// Node labels select the rates and node allocatable gives the idle capacity
+			cfg.ShowNode = true
+			// Own variable: the root command binds --group-by to cfg with another default
+			cfg.GroupBy = groupBy
+			cluster, errorsList := collectForCommand(cfg, args, false, func() error {
+				if pricingPath == "" {
+					return ErrInvalidCostOptions
+				}
+				switch kram.CostBasis(basis) {
+				case kram.CostByRequest, kram.CostByUsage, kram.CostByMax:
+				default:
+					return ErrInvalidCostOptions
+				}
+				var err error
+				pricing, err = kram.ReadPricing(pricingPath)
+				return err
+			})
+
+			// The idle capacity is only meaningful when every pod of the nodes was collected
+			wholeCluster := cfg.Namespace == "" && cfg.LabelSelector == "" && cfg.FieldSelector == "" && cfg.NamespaceSelector == ""
+			renderReport(cfg.OutputFormat, costReport(cluster, pricing, kram.CostBasis(basis), cfg.Grouping(), cfg.Namespace, wholeCluster), errorsList)
+
+			if !renderers[cfg.OutputFormat].embedsErrors {
+				printErrors(errorsList)
+			}
+		},
+	}
+
+	addCollectionFlags(costCmd, cfg, "Report on every namespace")
+	addSamplingFlags(costCmd, cfg, "Sample usage over this window and charge the average usage (e.g. 1h)")
+	costCmd.Flags().StringVar(&pricingPath, "pricing", "", "YAML file with the vCPU-hour and GiB-hour rates, optionally per node label")
+	costCmd.Flags().StringVar(&basis, "basis", string(kram.CostByMax), "Quantity charged per container: request, usage or max (the highest of both)")
+	costCmd.Flags().StringVar(&groupBy, "group-by", "label:team", "Also report the cost per value of label:<key>, annotation:<key> or node-label:<key> (empty to skip)")
+
+	return costCmd
+}
+
+// costLine accumulates the pods and charged resources of a namespace or a group
+type costLine struct {
+	name    string
+	pods    int
+	charged kram.Resources
+	cost    kram.Cost
+}
+
+// addCostLine groups pod costs under key, keeping the first-seen order. Completed pods are not counted.
+func addCostLine(lines []*costLine, index map[string]*costLine, key string, pc kram.PodCost) []*costLine {
+	line, ok := index[key]
+	if !ok {
+		line = &costLine{name: key}
+		index[key] = line
+		lines = append(lines, line)
+	}
+	if !pc.Pod.Completed() {
+		line.pods++
+	}
+	line.charged.Add(pc.Charged)
+	line.cost.Add(pc.Cost)
+	return lines
+}
+
+func costReport(cluster *kram.Cluster, pricing *kram.Pricing, basis kram.CostBasis, by kram.GroupBy, namespace string, wholeCluster bool) *report {
+	currency := pricing.Currency
+
+	var namespaces, groups []*costLine
+	namespaceIndex := make(map[string]*costLine)
+	groupIndex := make(map[string]*costLine)
+	groupOf := cluster.GroupOf(by)
+	var total kram.Cost
+	for _, pc := range kram.PodCosts(cluster, pricing, basis) {
+		namespaces = addCostLine(namespaces, namespaceIndex, pc.Pod.Namespace, pc)
+		if !by.IsZero() {
+			groups = addCostLine(groups, groupIndex, groupOf(pc.Pod), pc)
+		}
+		total.Add(pc.Cost)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return kram.GroupLess(groups[i].name, groups[j].name) })
+
+	doc := newJSONDocument("cost", namespace)
+	doc.GroupBy = by.String()
+	doc.Cost = &jsonCost{Currency: currency, Basis: string(basis)}
+
+	header := []string{"Pods", "CPU Charged", "Mem Charged", "CPU Cost/h", "Mem Cost/h", "Hourly", "Monthly"}
+	lineRow := func(name string, pods string, charged kram.Resources, cost kram.Cost) []string {
+		return []string{name, pods, formatCPU(charged.CPU), formatMemory(charged.Memory),
+			formatMoney(cost.CPU, 4, currency), formatMoney(cost.Memory, 4, currency),
+			formatMoney(cost.Total(), 4, currency), formatMoney(cost.Total()*kram.HoursPerMonth, 2, currency)}
+	}
+
+	nsTableData := [][]string{append([]string{"Namespace"}, header...)}
+	for _, line := range namespaces {
+		nsTableData = append(nsTableData, lineRow(line.name, strconv.Itoa(line.pods), line.charged, line.cost))
+		doc.Cost.Namespaces = append(doc.Cost.Namespaces, newJSONCostEntry(line.name, line.pods, line.charged, line.cost))
+	}
+
+	groupTableData := [][]string{append([]string{by.String()}, header...)}
+	for _, line := range groups {
+		groupTableData = append(groupTableData, lineRow(line.name, strconv.Itoa(line.pods), line.charged, line.cost))
+		doc.Cost.Groups = append(doc.Cost.Groups, newJSONCostEntry(line.name, line.pods, line.charged, line.cost))
+	}
+
+	var nodeTableData [][]string
+	if wholeCluster && len(cluster.Nodes) > 0 {
+		var idle kram.Resources
+		var idleCost kram.Cost
+		nodeTableData = [][]string{{"Node", "Pricing", "Pods", "CPU Allocatable", "Mem Allocatable", "CPU Charged", "Mem Charged", "Allocated/h", "Idle/h", "Hourly", "Monthly"}}
+		for _, nc := range kram.NodeCosts(cluster, pricing, basis) {
+			nodeTableData = append(nodeTableData, []string{
+				nc.Node.Name, nc.Rule, strconv.Itoa(nc.Pods),
+				formatCPU(nc.Node.Allocatable.CPU), formatMemory(nc.Node.Allocatable.Memory),
+				formatCPU(nc.Charged.CPU), formatMemory(nc.Charged.Memory),
+				formatMoney(nc.Allocated.Total(), 4, currency), formatMoney(nc.Idle.Total(), 4, currency),
+				formatMoney(nc.Cost.Total(), 4, currency), formatMoney(nc.Cost.Total()*kram.HoursPerMonth, 2, currency),
+			})
+			doc.Cost.Nodes = append(doc.Cost.Nodes, newJSONNodeCost(nc))
+			idle.Add(nc.Unallocated)
+			idleCost.Add(nc.Idle)
+		}
+		nsTableData = append(nsTableData, lineRow("Idle capacity", "", idle, idleCost))
+		idleEntry := newJSONCostEntry("", 0, idle, idleCost)
+		doc.Cost.Idle = &idleEntry
+		total.Add(idleCost)
+	}
+	nsTableData = append(nsTableData, []string{"Total", "", "", "",
+		formatMoney(total.CPU, 4, currency), formatMoney(total.Memory, 4, currency),
+		formatMoney(total.Total(), 4, currency), formatMoney(total.Total()*kram.HoursPerMonth, 2, currency)})
+	doc.Cost.Total = newJSONCostAmount(total)
+	doc.Total = newJSONResources(cluster.Total())
+
+	sections := []reportSection{{Title: fmt.Sprintf("Cost per namespace — charged on %s", basis), Data: nsTableData}}
+	if !by.IsZero() {
+		sections = append(sections, reportSection{Title: fmt.Sprintf("Cost per %s", by), Data: groupTableData})
+	}
+	if nodeTableData != nil {
+		sections = append(sections, reportSection{Title: "Cost per node — allocatable", Data: nodeTableData})
+	}
+
+	name := "kram-cost"
+	if namespace != "" {
+		name = fmt.Sprintf("kram-cost-%s", namespace)
+	}
+	return &report{Name: name, Sections: sections, Document: doc}
+}
+
+// roundMoney keeps 6 decimals of an hourly amount in documents
+func roundMoney(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
+func newJSONCostAmount(c kram.Cost) jsonCostAmount {
+	return jsonCostAmount{
+		CPUHourly:    roundMoney(c.CPU),
+		MemoryHourly: roundMoney(c.Memory),
+		Hourly:       roundMoney(c.Total()),
+		Monthly:      roundVal(c.Total() * kram.HoursPerMonth),
+	}
+}
+
+func newJSONCostEntry(name string, pods int, charged kram.Resources, c kram.Cost) jsonCostEntry {
+	return jsonCostEntry{
+		Name:           name,
+		Pods:           pods,
+		ChargedCPU:     charged.CPU,
+		ChargedMemory:  charged.Memory,
+		jsonCostAmount: newJSONCostAmount(c),
+	}
+}
+
+func newJSONNodeCost(nc kram.NodeCost) jsonNodeCost {
+	return jsonNodeCost{
+		jsonCostEntry:     newJSONCostEntry(nc.Node.Name, nc.Pods, nc.Charged, nc.Cost),
+		Pricing:           nc.Rule,
+		AllocatableCPU:    nc.Node.Allocatable.CPU,
+		AllocatableMemory: nc.Node.Allocatable.Memory,
+		AllocatedHourly:   roundMoney(nc.Allocated.Total()),
+		IdleHourly:        roundMoney(nc.Idle.Total()),
+	}
+}
+
+// csvCostRows flattens the cost document: one row per namespace, group and node, then idle and total
+func csvCostRows(cost *jsonCost) [][]string {
+	rows := [][]string{{"scope", "name", "pods", "charged_cpu_millicores", "charged_memory_bytes", "cpu_hourly", "memory_hourly", "hourly", "monthly", "currency"}}
+	entry := func(scope string, e jsonCostEntry) []string {
+		return []string{scope, e.Name, strconv.Itoa(e.Pods), strconv.FormatInt(e.ChargedCPU, 10), strconv.FormatInt(e.ChargedMemory, 10),
+			strconv.FormatFloat(e.CPUHourly, 'f', -1, 64), strconv.FormatFloat(e.MemoryHourly, 'f', -1, 64),
+			strconv.FormatFloat(e.Hourly, 'f', -1, 64), strconv.FormatFloat(e.Monthly, 'f', -1, 64), cost.Currency}
+	}
+	for _, e := range cost.Namespaces {
+		rows = append(rows, entry("namespace", e))
+	}
+	for _, e := range cost.Groups {
+		rows = append(rows, entry("group", e))
+	}
+	for _, n := range cost.Nodes {
+		rows = append(rows, entry("node", n.jsonCostEntry))
+	}
+	if cost.Idle != nil {
+		rows = append(rows, entry("idle", *cost.Idle))
+	}
+	return append(rows, entry("total", jsonCostEntry{jsonCostAmount: cost.Total}))
+}
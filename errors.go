@@ -3,7 +3,27 @@ package main
 import "errors"
 
 var (
-	ErrInvalidOutput      = errors.New("invalid --output value. Use 'table' or 'html'")
+	ErrInvalidOutput      = errors.New("invalid --output value")
 	ErrFlagOnlyWithNode   = errors.New("flags --cpu / --ram are only effective with -N")
 	ErrKubeconfigNotFound = errors.New("kubeconfig file not found")
+	ErrNoClusterConfig    = errors.New("no kubeconfig found and not running inside a Kubernetes pod")
+
+	ErrNamespaceConflict         = errors.New("namespace given both as argument and with -n / --namespace")
+	ErrAllNamespacesConflict     = errors.New("flag -A / --all-namespaces cannot be combined with a namespace")
+	ErrNamespaceSelectorConflict = errors.New("flag --namespace-selector cannot be combined with a namespace")
+	ErrInvalidSelector           = errors.New("invalid --field-selector or --namespace-selector value")
+	ErrMultiClusterView          = errors.New("flags --contexts / --kubeconfig-glob only support the global namespaces view")
+	ErrInvalidRecommendOptions   = errors.New("--headroom must be positive and --limit-ratio 0 or at least 1")
+	ErrInvalidSamplingWindow     = errors.New("--duration must be at least one --interval")
+	ErrSamplingWindowSource      = errors.New("flag --duration cannot be combined with --contexts, --kubeconfig-glob or --from-snapshot")
+	ErrInvalidWatchInterval      = errors.New("--watch interval must be positive")
+	ErrWatchConflict             = errors.New("flag --watch only supports the table output of a live cluster (no --duration, --contexts, --kubeconfig-glob or --from-snapshot)")
+	ErrInvalidWasteOptions       = errors.New("--by must be pod, workload or namespace, --sort-by cpu or memory and --top not negative")
+	ErrInvalidCheckOptions       = errors.New("--policy is required")
+	ErrInvalidCostOptions        = errors.New("--pricing is required and --basis must be request, usage or max")
+	ErrInvalidOrder              = errors.New("invalid --sort-by or negative --top value")
+	ErrInvalidGroupBy            = errors.New("invalid --group-by value")
+	ErrGroupByView               = errors.New("flag --group-by only applies to the namespaces view and the node views (-N)")
+	ErrWorkloadsView             = errors.New("flag --workloads cannot be combined with -N, --group-by, --contexts or --kubeconfig-glob")
+	ErrNoKubeconfigMatch         = errors.New("no kubeconfig file matches --kubeconfig-glob")
 )
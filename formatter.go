@@ -5,12 +5,16 @@ import (
 	"math"
 	"strings"
 
+	"github.com/PaulPowershell/Kram/pkg/kram"
 	"github.com/docker/go-units"
 )
 
 // Formatting constants
 const (
 	MiBPerB = 1_048_576
+	// Unset stands for a request or limit no container of the row sets, unlike an explicit 0,
+	// and for any cell with nothing to show (no pods on a node, a percentage of nothing)
+	Unset = "—"
 )
 
 // formatBytes converts bytes to human-readable format
@@ -42,6 +46,19 @@ func formatMemory(bytes int64) string {
 	return fmt.Sprintf("%.1f MiB", toMiB(bytes))
 }
 
+// formatPercent formats part as a percentage of whole, Unset when whole is unknown
+func formatPercent(part, whole int64) string {
+	if whole == 0 {
+		return Unset
+	}
+	return fmt.Sprintf("%.1f %%", float64(part)*100/float64(whole))
+}
+
+// formatMoney formats an amount with the currency of the pricing file
+func formatMoney(amount float64, decimals int, currency string) string {
+	return strings.TrimSpace(fmt.Sprintf("%.*f %s", decimals, amount, currency))
+}
+
 // shortNodeName shortens a node name by keeping first 2 parts and last 2 chars
 func shortNodeName(name string) string {
 	parts := strings.Split(name, "-")
@@ -58,3 +75,85 @@ func shortNodeName(name string) string {
 	suffix := name[len(name)-2:]
 	return fmt.Sprintf("%s-%s", prefix, suffix)
 }
+
+// orUnset returns s, or Unset when the request or limit is not set
+func orUnset(set bool, s string) string {
+	if !set {
+		return Unset
+	}
+	return s
+}
+
+// formatQuantities formats usage, request and limit as CPU then memory table cells
+func formatQuantities(q kram.Quantities) []string {
+	return []string{
+		formatCPU(q.Usage.CPU),
+		orUnset(q.Set.CPURequest, formatCPU(q.Request.CPU)),
+		orUnset(q.Set.CPULimit, formatCPU(q.Limit.CPU)),
+		formatMemory(q.Usage.Memory),
+		orUnset(q.Set.MemoryRequest, formatMemory(q.Request.Memory)),
+		orUnset(q.Set.MemoryLimit, formatMemory(q.Limit.Memory)),
+	}
+}
+
+// formatSampledQuantities formats like formatQuantities, the usage cells showing min/avg/p50/p95/max of the samples
+func formatSampledQuantities(q kram.Quantities, samples []kram.Resources) []string {
+	cells := formatQuantities(q)
+	if len(samples) <= 1 {
+		return cells
+	}
+	s := kram.SampleStats(samples)
+	cells[0] = fmt.Sprintf("%d/%d/%d/%d/%d m", s.Min.CPU, s.Avg.CPU, s.P50.CPU, s.P95.CPU, s.Max.CPU)
+	cells[3] = fmt.Sprintf("%.1f/%.1f/%.1f/%.1f/%.1f MiB", toMiB(s.Min.Memory), toMiB(s.Avg.Memory), toMiB(s.P50.Memory), toMiB(s.P95.Memory), toMiB(s.Max.Memory))
+	return cells
+}
+
+// quantitiesHeader returns the column titles matching formatQuantities and formatSampledQuantities
+func quantitiesHeader(sampled bool) []string {
+	if sampled {
+		return []string{"CPU Usage (min/avg/p50/p95/max)", "CPU Request", "CPU Limit", "Mem Usage (min/avg/p50/p95/max)", "Mem Request", "Mem Limit"}
+	}
+	return []string{"CPU Usage", "CPU Request", "CPU Limit", "Mem Usage", "Mem Request", "Mem Limit"}
+}
+
+// podCountsHeader returns the column titles matching formatPodCounts
+func podCountsHeader() []string {
+	return []string{"Running", "Pending", "Completed"}
+}
+
+// formatPodCounts formats the pods of each phase as table cells
+func formatPodCounts(c kram.PodCounts) []string {
+	return []string{fmt.Sprint(c.Running), fmt.Sprint(c.Pending), fmt.Sprint(c.Completed)}
+}
+
+// withEfficiencyHeader appends the efficiency column titles when enabled
+func withEfficiencyHeader(header []string, efficiency bool) []string {
+	if !efficiency {
+		return header
+	}
+	return append(header, "CPU Usage/Req", "CPU Req/Lim", "CPU Wasted", "Mem Usage/Req", "Mem Req/Lim", "Mem Wasted")
+}
+
+// withEfficiencyCells appends usage/request, request/limit and the wasted request, CPU then memory, when enabled
+func withEfficiencyCells(row []string, q kram.Quantities, waste kram.Resources, efficiency bool) []string {
+	if !efficiency {
+		return row
+	}
+	return append(row,
+		formatPercent(q.Usage.CPU, q.Request.CPU), formatPercent(q.Request.CPU, q.Limit.CPU), formatCPU(waste.CPU),
+		formatPercent(q.Usage.Memory, q.Request.Memory), formatPercent(q.Request.Memory, q.Limit.Memory), formatMemory(waste.Memory))
+}
+
+// formatCPUCell formats usage/request/limit millicores in a single matrix cell
+func formatCPUCell(q kram.Quantities) string {
+	return fmt.Sprintf("%dm/%s/%s", q.Usage.CPU,
+		orUnset(q.Set.CPURequest, fmt.Sprintf("%dm", q.Request.CPU)),
+		orUnset(q.Set.CPULimit, fmt.Sprintf("%dm", q.Limit.CPU)))
+}
+
+// formatMemoryCell formats usage/request/limit bytes as MiB in a single matrix cell
+func formatMemoryCell(q kram.Quantities) string {
+	return fmt.Sprintf("%.1f/%s/%s MiB", toMiB(q.Usage.Memory),
+		orUnset(q.Set.MemoryRequest, fmt.Sprintf("%.1f", toMiB(q.Request.Memory))),
+		orUnset(q.Set.MemoryLimit, fmt.Sprintf("%.1f", toMiB(q.Limit.Memory))))
+}
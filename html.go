@@ -2,12 +2,14 @@ package main
 
 import (
 	"fmt"
+	"html"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
 
+	"github.com/PaulPowershell/Kram/pkg/kram"
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/components"
 	"github.com/go-echarts/go-echarts/v2/opts"
@@ -23,11 +25,6 @@ type barChartSeries struct {
 	values []float64
 }
 
-type htmlSection struct {
-	Title string
-	Data  [][]string
-}
-
 // ============================================================
 // CONSTANTS
 // ============================================================
@@ -129,6 +126,39 @@ func newBarChart(series []barChartSeries, xLabels []string, title string, yLabel
 	return bar
 }
 
+// quantitiesBarCharts builds the CPU and memory Usage / Request / Limit charts of the given entries
+func quantitiesBarCharts(xLabels []string, values []kram.Quantities, cpuTitle string, memTitle string) (*charts.Bar, *charts.Bar) {
+	cpuUsageVals := make([]float64, len(values))
+	cpuReqVals := make([]float64, len(values))
+	cpuLimVals := make([]float64, len(values))
+	memUsageVals := make([]float64, len(values))
+	memReqVals := make([]float64, len(values))
+	memLimVals := make([]float64, len(values))
+
+	for i, q := range values {
+		cpuUsageVals[i] = float64(q.Usage.CPU)
+		cpuReqVals[i] = float64(q.Request.CPU)
+		cpuLimVals[i] = float64(q.Limit.CPU)
+		memUsageVals[i] = toMiB(q.Usage.Memory)
+		memReqVals[i] = toMiB(q.Request.Memory)
+		memLimVals[i] = toMiB(q.Limit.Memory)
+	}
+
+	cpuBarChart := newBarChart([]barChartSeries{
+		{name: "Usage", values: cpuUsageVals},
+		{name: "Request", values: cpuReqVals},
+		{name: "Limit", values: cpuLimVals},
+	}, xLabels, cpuTitle, "millicores")
+
+	memBarChart := newBarChart([]barChartSeries{
+		{name: "Usage", values: memUsageVals},
+		{name: "Request", values: memReqVals},
+		{name: "Limit", values: memLimVals},
+	}, xLabels, memTitle, "MiB")
+
+	return cpuBarChart, memBarChart
+}
+
 func barBodySnippet(bars ...*charts.Bar) (string, string) {
 	var scriptTag string
 	var snippets []string
@@ -180,7 +210,24 @@ func barBodySnippet(bars ...*charts.Bar) (string, string) {
 // HTML RENDERER
 // ============================================================
 
-func renderHTML(sections []htmlSection, filename string, chartHead string, chartBody string) {
+func renderHTMLReport(r *report) error {
+	chartHead, chartBody := barBodySnippet(r.Charts...)
+	renderHTML(r.Sections, htmlOutputPath(r.Name+".html"), chartHead, chartBody)
+	return nil
+}
+
+func renderHTML(sections []reportSection, filename string, chartHead string, chartBody string) {
+	if err := os.WriteFile(filename, []byte(htmlPage(sections, chartHead, chartBody, "")), 0644); err != nil {
+		pterm.Error.Println("Cannot write HTML file:", err)
+		os.Exit(1)
+	}
+
+	pterm.Success.Println("HTML report generated:", filename)
+	openBrowser(filename)
+}
+
+// htmlPage builds the report page; nav is inserted as-is under the title (links of kram serve)
+func htmlPage(sections []reportSection, chartHead string, chartBody string, nav string) string {
 	var sb strings.Builder
 
 	sb.WriteString(`<!DOCTYPE html>
@@ -197,6 +244,8 @@ func renderHTML(sections []htmlSection, filename string, chartHead string, chart
     body { font-family: monospace; background: #f5f5f5; color: #1e1e1e; padding: 20px; }
     h1 { color: #1a56a0; }
     h2 { color: #1a56a0; margin-top: 30px; }
+    summary { cursor: pointer; }
+    summary h2 { display: inline-block; }
     .table-wrapper { overflow-x: auto; margin-bottom: 30px; }
     table { border-collapse: collapse; white-space: nowrap; min-width: 100%; }
     th { background: #1a56a0; color: #ffffff; padding: 8px 14px; border: 1px solid #c0c0c0; text-align: left; }
@@ -209,25 +258,36 @@ func renderHTML(sections []htmlSection, filename string, chartHead string, chart
 <body>
   <h1>Kram - Kubernetes Resource Metrics</h1>
 `)
+	if nav != "" {
+		sb.WriteString("  " + nav + "\n")
+	}
 
 	for _, section := range sections {
-		sb.WriteString(fmt.Sprintf("  <h2>%s</h2>\n  <div class=\"table-wrapper\">\n  <table>\n", section.Title))
+		if section.Detail {
+			sb.WriteString(fmt.Sprintf("  <details>\n  <summary><h2>%s</h2></summary>\n", html.EscapeString(section.Title)))
+		} else {
+			sb.WriteString(fmt.Sprintf("  <h2>%s</h2>\n", html.EscapeString(section.Title)))
+		}
+		sb.WriteString("  <div class=\"table-wrapper\">\n  <table>\n")
 		for i, row := range section.Data {
 			if i == 0 {
 				sb.WriteString("    <thead><tr>")
 				for _, cell := range row {
-					sb.WriteString(fmt.Sprintf("<th>%s</th>", cell))
+					sb.WriteString(fmt.Sprintf("<th>%s</th>", html.EscapeString(cell)))
 				}
 				sb.WriteString("</tr></thead>\n    <tbody>\n")
 			} else {
 				sb.WriteString("    <tr>")
 				for _, cell := range row {
-					sb.WriteString(fmt.Sprintf("<td>%s</td>", cell))
+					sb.WriteString(fmt.Sprintf("<td>%s</td>", html.EscapeString(cell)))
 				}
 				sb.WriteString("</tr>\n")
 			}
 		}
 		sb.WriteString("    </tbody>\n  </table>\n  </div>\n")
+		if section.Detail {
+			sb.WriteString("  </details>\n")
+		}
 	}
 
 	if chartBody != "" {
@@ -235,12 +295,5 @@ func renderHTML(sections []htmlSection, filename string, chartHead string, chart
 	}
 
 	sb.WriteString("</body>\n</html>")
-
-	if err := os.WriteFile(filename, []byte(sb.String()), 0644); err != nil {
-		pterm.Error.Println("Cannot write HTML file:", err)
-		os.Exit(1)
-	}
-
-	pterm.Success.Println("HTML report generated:", filename)
-	openBrowser(filename)
+	return sb.String()
 }
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/PaulPowershell/Kram/pkg/kram"
+)
+
+// ============================================================
+// DOCUMENT (kram -o json / yaml / csv)
+// ============================================================
+
+// jsonAPIVersion is bumped whenever a field is renamed or removed from the document.
+const jsonAPIVersion = "kram/v1"
+
+type jsonDocument struct {
+	APIVersion string `json:"apiVersion"`
+	View       string `json:"view"`
+	Namespace  string `json:"namespace,omitempty"`
+	// GroupBy is set when --group-by replaces the namespaces (or the nodes of the node views) by label values
+	GroupBy     string          `json:"groupBy,omitempty"`
+	GeneratedAt time.Time       `json:"generatedAt"`
+	Clusters    []jsonCluster   `json:"clusters,omitempty"`
+	Namespaces  []jsonNamespace `json:"namespaces,omitempty"`
+	Pods        []jsonPod       `json:"pods,omitempty"`
+	Nodes       []jsonNode      `json:"nodes,omitempty"`
+	// Recommendations is only set by the recommend view
+	Recommendations []jsonRecommendation `json:"recommendations,omitempty"`
+	// Workloads is only set by the workloads view
+	Workloads []jsonWorkload `json:"workloads,omitempty"`
+	// Wasters is only set by the waste view, highest waste first
+	Wasters []jsonWaster `json:"wasters,omitempty"`
+	// Violations is only set by the check view, errors first
+	Violations []jsonViolation `json:"violations,omitempty"`
+	// Missing is only set by the missing view
+	Missing []jsonMissing `json:"missing,omitempty"`
+	// Cost is only set by the cost view
+	Cost   *jsonCost     `json:"cost,omitempty"`
+	Total  jsonResources `json:"total"`
+	Errors []jsonError   `json:"errors"`
+}
+
+// jsonResources holds raw quantities: CPU in millicores, memory in bytes.
+type jsonResources struct {
+	CPUUsage      int64  `json:"cpuUsageMillicores"`
+	CPURequest    *int64 `json:"cpuRequestMillicores,omitempty"`
+	CPULimit      *int64 `json:"cpuLimitMillicores,omitempty"`
+	MemoryUsage   int64  `json:"memoryUsageBytes"`
+	MemoryRequest *int64 `json:"memoryRequestBytes,omitempty"`
+	MemoryLimit   *int64 `json:"memoryLimitBytes,omitempty"`
+	// Unset names the requests and limits no container sets (cpuRequest, cpuLimit, memoryRequest,
+	// memoryLimit): their field is omitted rather than an explicit 0
+	Unset []string `json:"unset,omitempty"`
+	// UsageStats is only set with --duration; usage is then the average of the window
+	UsageStats *jsonUsageStats `json:"usageStats,omitempty"`
+	// Efficiency is only set with --efficiency
+	Efficiency *jsonEfficiency `json:"efficiency,omitempty"`
+}
+
+// jsonEfficiency holds ratios (0.5 for 50 %), omitted when the request or limit is not set
+type jsonEfficiency struct {
+	CPUUsageToRequest    *float64 `json:"cpuUsageToRequest,omitempty"`
+	CPURequestToLimit    *float64 `json:"cpuRequestToLimit,omitempty"`
+	MemoryUsageToRequest *float64 `json:"memoryUsageToRequest,omitempty"`
+	MemoryRequestToLimit *float64 `json:"memoryRequestToLimit,omitempty"`
+	WastedCPU            int64    `json:"wastedCpuMillicores"`
+	WastedMemory         int64    `json:"wastedMemoryBytes"`
+}
+
+type jsonUsageStats struct {
+	Samples   int   `json:"samples"`
+	CPUMin    int64 `json:"cpuMinMillicores"`
+	CPUAvg    int64 `json:"cpuAvgMillicores"`
+	CPUP50    int64 `json:"cpuP50Millicores"`
+	CPUP95    int64 `json:"cpuP95Millicores"`
+	CPUMax    int64 `json:"cpuMaxMillicores"`
+	MemoryMin int64 `json:"memoryMinBytes"`
+	MemoryAvg int64 `json:"memoryAvgBytes"`
+	MemoryP50 int64 `json:"memoryP50Bytes"`
+	MemoryP95 int64 `json:"memoryP95Bytes"`
+	MemoryMax int64 `json:"memoryMaxBytes"`
+}
+
+type jsonCluster struct {
+	Name string `json:"name"`
+	Pods int    `json:"pods"`
+	*jsonPodCounts
+	jsonResources
+	Namespaces []jsonNamespace `json:"namespaces,omitempty"`
+}
+
+type jsonNamespace struct {
+	Name string `json:"name"`
+	Pods int    `json:"pods,omitempty"`
+	// jsonPodCounts splits pods by phase in the namespaces and clusters views
+	*jsonPodCounts
+	jsonResources
+	Nodes []jsonNode `json:"nodes,omitempty"`
+}
+
+// jsonPodCounts counts pods by phase; completed pods (Succeeded or Failed) hold no request nor limit
+type jsonPodCounts struct {
+	Running   int `json:"running"`
+	Pending   int `json:"pending"`
+	Completed int `json:"completed"`
+}
+
+type jsonPod struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Node      string `json:"node,omitempty"`
+	// Phase is only set by the namespace view
+	Phase string `json:"phase,omitempty"`
+	jsonResources
+	Containers []jsonContainer `json:"containers,omitempty"`
+}
+
+type jsonContainer struct {
+	Name string `json:"name"`
+	// Kind is container, init, sidecar or ephemeral
+	Kind string `json:"kind"`
+	jsonResources
+}
+
+type jsonNode struct {
+	Name string `json:"name"`
+	jsonResources
+	// Capacity, Allocatable and NodeUsage are only set when Node objects could be listed
+	Capacity    *jsonNodeResources `json:"capacity,omitempty"`
+	Allocatable *jsonNodeResources `json:"allocatable,omitempty"`
+	NodeUsage   *jsonNodeResources `json:"nodeUsage,omitempty"`
+}
+
+// jsonNodeResources holds raw node quantities: CPU in millicores, memory in bytes.
+type jsonNodeResources struct {
+	CPU    int64 `json:"cpuMillicores"`
+	Memory int64 `json:"memoryBytes"`
+}
+
+type jsonRecommendation struct {
+	Namespace    string `json:"namespace"`
+	WorkloadKind string `json:"workloadKind"`
+	Workload     string `json:"workload"`
+	Container    string `json:"container"`
+	Kind         string `json:"kind"`
+	Pods         int    `json:"pods"`
+	// Peak usage across replicas, current request and limit
+	jsonResources
+	Recommended  jsonRecommended `json:"recommended"`
+	CPUStatus    string          `json:"cpuStatus"`
+	MemoryStatus string          `json:"memoryStatus"`
+}
+
+// jsonRecommended omits the limits when none is recommended (--limit-ratio 0)
+type jsonRecommended struct {
+	CPURequest    int64  `json:"cpuRequestMillicores"`
+	CPULimit      *int64 `json:"cpuLimitMillicores,omitempty"`
+	MemoryRequest int64  `json:"memoryRequestBytes"`
+	MemoryLimit   *int64 `json:"memoryLimitBytes,omitempty"`
+}
+
+// jsonWorkload sums the replicas of a top-level controller; PerReplica averages those with metrics
+type jsonWorkload struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Replicas  int    `json:"replicas"`
+	jsonResources
+	PerReplica jsonResources `json:"perReplica"`
+	Pods       []jsonPod     `json:"pods"`
+}
+
+// jsonWaster is a pod, a workload (kind/name) or a namespace (empty name) of the waste view
+type jsonWaster struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name,omitempty"`
+	jsonResources
+}
+
+// jsonViolation is a subject breaking a rule of the check policy; name is empty for a namespace
+type jsonViolation struct {
+	Rule      string `json:"rule"`
+	Severity  string `json:"severity"`
+	Scope     string `json:"scope"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name,omitempty"`
+	Message   string `json:"message"`
+}
+
+// jsonMissing is a container not setting the requests and limits named in unset
+type jsonMissing struct {
+	Namespace    string   `json:"namespace"`
+	WorkloadKind string   `json:"workloadKind"`
+	Workload     string   `json:"workload"`
+	Pod          string   `json:"pod"`
+	Container    string   `json:"container"`
+	Kind         string   `json:"kind"`
+	QOSClass     string   `json:"qosClass"`
+	Unset        []string `json:"unset"`
+}
+
+// jsonCost holds amounts in the currency of the pricing file; monthly amounts are 730 hours
+type jsonCost struct {
+	Currency   string          `json:"currency"`
+	Basis      string          `json:"basis"`
+	Namespaces []jsonCostEntry `json:"namespaces"`
+	// Groups holds the cost per value of the document groupBy, the team label by default
+	Groups []jsonCostEntry `json:"groups,omitempty"`
+	// Nodes and Idle are only set when every pod of the nodes was collected
+	Nodes []jsonNodeCost `json:"nodes,omitempty"`
+	Idle  *jsonCostEntry `json:"idle,omitempty"`
+	Total jsonCostAmount `json:"total"`
+}
+
+type jsonCostAmount struct {
+	CPUHourly    float64 `json:"cpuHourly"`
+	MemoryHourly float64 `json:"memoryHourly"`
+	Hourly       float64 `json:"hourly"`
+	Monthly      float64 `json:"monthly"`
+}
+
+type jsonCostEntry struct {
+	Name          string `json:"name,omitempty"`
+	Pods          int    `json:"pods,omitempty"`
+	ChargedCPU    int64  `json:"chargedCpuMillicores"`
+	ChargedMemory int64  `json:"chargedMemoryBytes"`
+	jsonCostAmount
+}
+
+// jsonNodeCost prices the allocatable of the node; allocated and idle split it
+type jsonNodeCost struct {
+	jsonCostEntry
+	Pricing           string  `json:"pricing"`
+	AllocatableCPU    int64   `json:"allocatableCpuMillicores"`
+	AllocatableMemory int64   `json:"allocatableMemoryBytes"`
+	AllocatedHourly   float64 `json:"allocatedHourly"`
+	IdleHourly        float64 `json:"idleHourly"`
+}
+
+type jsonError struct {
+	Message string `json:"message"`
+}
+
+// newJSONResources converts model quantities to their document representation
+func newJSONResources(q kram.Quantities) jsonResources {
+	return jsonResources{
+		CPUUsage:      q.Usage.CPU,
+		CPURequest:    jsonSet(q.Set.CPURequest, q.Request.CPU),
+		CPULimit:      jsonSet(q.Set.CPULimit, q.Limit.CPU),
+		MemoryUsage:   q.Usage.Memory,
+		MemoryRequest: jsonSet(q.Set.MemoryRequest, q.Request.Memory),
+		MemoryLimit:   jsonSet(q.Set.MemoryLimit, q.Limit.Memory),
+		Unset:         q.Set.Missing(),
+	}
+}
+
+// jsonSet returns v, nil when the request or limit is not set so that the field is omitted
+func jsonSet(set bool, v int64) *int64 {
+	if !set {
+		return nil
+	}
+	return &v
+}
+
+// newSampledJSONResources converts model quantities with the statistics of their usage samples
+func newSampledJSONResources(q kram.Quantities, samples []kram.Resources) jsonResources {
+	res := newJSONResources(q)
+	if len(samples) <= 1 {
+		return res
+	}
+	s := kram.SampleStats(samples)
+	res.UsageStats = &jsonUsageStats{
+		Samples:   len(samples),
+		CPUMin:    s.Min.CPU,
+		CPUAvg:    s.Avg.CPU,
+		CPUP50:    s.P50.CPU,
+		CPUP95:    s.P95.CPU,
+		CPUMax:    s.Max.CPU,
+		MemoryMin: s.Min.Memory,
+		MemoryAvg: s.Avg.Memory,
+		MemoryP50: s.P50.Memory,
+		MemoryP95: s.P95.Memory,
+		MemoryMax: s.Max.Memory,
+	}
+	return res
+}
+
+// withJSONEfficiency adds the efficiency ratios and the waste when enabled
+func withJSONEfficiency(res jsonResources, q kram.Quantities, waste kram.Resources, efficiency bool) jsonResources {
+	if !efficiency {
+		return res
+	}
+	res.Efficiency = &jsonEfficiency{
+		CPUUsageToRequest:    jsonRatio(q.Usage.CPU, q.Request.CPU),
+		CPURequestToLimit:    jsonRatio(q.Request.CPU, q.Limit.CPU),
+		MemoryUsageToRequest: jsonRatio(q.Usage.Memory, q.Request.Memory),
+		MemoryRequestToLimit: jsonRatio(q.Request.Memory, q.Limit.Memory),
+		WastedCPU:            waste.CPU,
+		WastedMemory:         waste.Memory,
+	}
+	return res
+}
+
+// jsonRatio returns part/whole rounded to 4 decimals, nil when whole is not set
+func jsonRatio(part, whole int64) *float64 {
+	if whole == 0 {
+		return nil
+	}
+	ratio := math.Round(float64(part)/float64(whole)*10000) / 10000
+	return &ratio
+}
+
+// newJSONNode converts pod quantities aggregated on a node, with the node capacity when known
+func newJSONNode(n kram.NodeQuantities, node *kram.Node) jsonNode {
+	entry := jsonNode{Name: n.Name, jsonResources: newJSONResources(n.Quantities)}
+	if node == nil {
+		return entry
+	}
+	entry.Capacity = &jsonNodeResources{CPU: node.Capacity.CPU, Memory: node.Capacity.Memory}
+	entry.Allocatable = &jsonNodeResources{CPU: node.Allocatable.CPU, Memory: node.Allocatable.Memory}
+	if node.HasMetrics {
+		entry.NodeUsage = &jsonNodeResources{CPU: node.Usage.CPU, Memory: node.Usage.Memory}
+	}
+	return entry
+}
+
+// newJSONRecommendation converts a recommendation to its document representation
+func newJSONRecommendation(rec kram.Recommendation) jsonRecommendation {
+	limitSet := rec.Limit != (kram.Resources{})
+	return jsonRecommendation{
+		Namespace:     rec.Namespace,
+		WorkloadKind:  rec.Workload.Kind,
+		Workload:      rec.Workload.Name,
+		Container:     rec.Container,
+		Kind:          string(rec.Kind),
+		Pods:          rec.Pods,
+		jsonResources: newJSONResources(rec.Current),
+		Recommended: jsonRecommended{
+			CPURequest:    rec.Request.CPU,
+			CPULimit:      jsonSet(limitSet, rec.Limit.CPU),
+			MemoryRequest: rec.Request.Memory,
+			MemoryLimit:   jsonSet(limitSet, rec.Limit.Memory),
+		},
+		CPUStatus:    string(rec.CPU),
+		MemoryStatus: string(rec.Memory),
+	}
+}
+
+// newJSONPodCounts converts model pod counts
+func newJSONPodCounts(c kram.PodCounts) *jsonPodCounts {
+	return &jsonPodCounts{Running: c.Running, Pending: c.Pending, Completed: c.Completed}
+}
+
+// newJSONDocument creates an empty document for the given view
+func newJSONDocument(view string, namespace string) jsonDocument {
+	return jsonDocument{
+		APIVersion:  jsonAPIVersion,
+		View:        view,
+		Namespace:   namespace,
+		GeneratedAt: time.Now().UTC(),
+	}
+}
+
+// document returns the report document with its errors. Records keep the order of the tables,
+// which the views build from kram.Arrange.
+func (r *report) document() jsonDocument {
+	doc := r.Document
+	doc.Errors = make([]jsonError, 0, len(r.Errors))
+	for _, err := range r.Errors {
+		doc.Errors = append(doc.Errors, jsonError{Message: err.Error()})
+	}
+	return doc
+}
+
+// renderJSON writes the report document to stdout
+func renderJSON(r *report) error {
+	return writeJSON(os.Stdout, r)
+}
+
+// writeJSON encodes the report document as indented JSON
+func writeJSON(w io.Writer, r *report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r.document())
+}
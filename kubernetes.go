@@ -1,16 +1,16 @@
 package main
 
 import (
-	"context"
+	"errors"
+	"fmt"
 	"os"
 	"sync"
 
-	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"github.com/PaulPowershell/Kram/pkg/kram"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
-	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
@@ -34,67 +34,58 @@ func suppressKubernetesLogs(fn func() error) (result error) {
 	return fn()
 }
 
-// buildClients creates Kubernetes and Metrics clientsets from kubeconfig
-func buildClients(kubeconfig string) (*kubernetes.Clientset, *metricsv.Clientset, error) {
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
-	if err != nil {
-		return nil, nil, err
+// connectClients returns clients for the live cluster, or serving the snapshot file when --from-snapshot is set
+func connectClients(cfg *Config) (kubernetes.Interface, metricsv.Interface, error) {
+	if cfg.FromSnapshot != "" {
+		snap, err := kram.ReadSnapshot(cfg.FromSnapshot)
+		if err != nil {
+			return nil, nil, err
+		}
+		return snap.Clients()
 	}
-	clientset, err := kubernetes.NewForConfig(config)
+
+	clientset, metricsClientset, err := buildClients(cfg)
 	if err != nil {
 		return nil, nil, err
 	}
-	metricsClientset, err := metricsv.NewForConfig(config)
-	if err != nil {
-		return nil, nil, err
+	if _, err := clientset.Discovery().ServerVersion(); err != nil {
+		return nil, nil, fmt.Errorf("cannot connect to Kubernetes cluster: %w", err)
 	}
 	return clientset, metricsClientset, nil
 }
 
-// getNamespacePodMetricsMap fetches all pod metrics for a namespace with a single API call
-// and returns them as a map for O(1) lookup instead of O(n) per-pod .Get() calls
-func getNamespacePodMetricsMap(ctx context.Context, metricsClientset *metricsv.Clientset, namespace string, errorsList *[]error, mu *sync.Mutex) map[string]*metricsv1beta1.PodMetrics {
-	result := make(map[string]*metricsv1beta1.PodMetrics)
-
-	var podMetricsList *metricsv1beta1.PodMetricsList
-	err := suppressKubernetesLogs(func() error {
-		var e error
-		podMetricsList, e = metricsClientset.MetricsV1beta1().PodMetricses(namespace).List(ctx, metav1.ListOptions{})
-		return e
-	})
-
-	if err != nil {
-		mu.Lock()
-		*errorsList = append(*errorsList, err)
-		mu.Unlock()
-		return result
-	}
-
-	for i := range podMetricsList.Items {
-		pod := &podMetricsList.Items[i]
-		result[pod.Name] = pod
+// restConfig resolves the client configuration like kubectl: --kubeconfig, else the files of
+// $KUBECONFIG merged in order, else ~/.kube/config, then applies the connection flag overrides.
+// Without any kubeconfig it falls back to the service account of the pod Kram runs in.
+func restConfig(cfg *Config) (*rest.Config, error) {
+	config, err := cfg.KubeFlags.ToRESTConfig()
+	if clientcmd.IsEmptyConfig(err) {
+		config, err = rest.InClusterConfig()
+		if errors.Is(err, rest.ErrNotInCluster) {
+			return nil, ErrNoClusterConfig
+		}
 	}
-
-	return result
+	return config, err
 }
 
-// getContainerMetricsMap creates a map of container name -> metrics for O(1) lookup
-// Avoids O(n*m) double loop when matching containers to metrics
-func getContainerMetricsMap(podMetrics *metricsv1beta1.PodMetrics) map[string]*metricsv1beta1.ContainerMetrics {
-	result := make(map[string]*metricsv1beta1.ContainerMetrics, len(podMetrics.Containers))
-	for i := range podMetrics.Containers {
-		container := &podMetrics.Containers[i]
-		result[container.Name] = container
+// buildClients creates Kubernetes and Metrics clientsets from the resolved kubeconfig
+func buildClients(cfg *Config) (*kubernetes.Clientset, *metricsv.Clientset, error) {
+	config, err := restConfig(cfg)
+	if err != nil {
+		return nil, nil, err
 	}
-	return result
+	return clientsForConfig(config)
 }
 
-// getContainerSpecMap creates a map of container name -> spec for O(1) lookup
-func getContainerSpecMap(pod *corev1.Pod) map[string]*corev1.Container {
-	result := make(map[string]*corev1.Container, len(pod.Spec.Containers))
-	for i := range pod.Spec.Containers {
-		container := &pod.Spec.Containers[i]
-		result[container.Name] = container
+// clientsForConfig creates Kubernetes and Metrics clientsets from a resolved REST config
+func clientsForConfig(config *rest.Config) (*kubernetes.Clientset, *metricsv.Clientset, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, err
 	}
-	return result
+	metricsClientset, err := metricsv.NewForConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return clientset, metricsClientset, nil
 }
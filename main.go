@@ -2,12 +2,17 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"atomicgo.dev/cursor"
+	"github.com/PaulPowershell/Kram/pkg/kram"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
-	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
 // ============================================================
@@ -34,75 +39,243 @@ func main() {
 		Long:  "Kram retrieves resource metrics for Kubernetes namespaces and pods and prints them in a tabular format.",
 		Args:  cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
+			// Keep stdout clean for machine-readable output: decorations go to stderr
+			if renderers[cfg.OutputFormat].machineReadable {
+				pterm.SetDefaultOutput(os.Stderr)
+				cursor.SetTarget(os.Stderr)
+			}
+
 			spinner, _ := pterm.DefaultSpinner.Start("Initialization running")
 
 			var errorsList []error
 
-			if len(args) > 0 {
-				cfg.Namespace = args[0]
-			}
-
-			if err := cfg.Validate(); err != nil {
+			if err := cfg.ResolveNamespace(args); err != nil {
 				spinner.Fail("Initialization error")
 				pterm.Error.Println(err)
 				os.Exit(1)
 			}
 
-			clientset, metricsClientset, err := buildClients(cfg.Kubeconfig)
-			if err != nil {
+			if err := cfg.Validate(); err != nil {
 				spinner.Fail("Initialization error")
-				pterm.Error.WithShowLineNumber(true).Println(err)
+				pterm.Error.Println(err)
 				os.Exit(1)
 			}
 
-			if _, err := clientset.Discovery().ServerVersion(); err != nil {
-				spinner.Fail("Initialization error")
-				pterm.Error.WithShowLineNumber(true).Println("Cannot connect to Kubernetes cluster:", err)
-				os.Exit(1)
-			}
+			var r *report
+			var view string
 
-			spinner.Success("Initialization done")
-
-			if cfg.ShowNode {
-				if cfg.Namespace != "" {
-					namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: cfg.Namespace}}
-					listPodNodeMetrics(*namespace, clientset, metricsClientset, cfg.ShowCPUOnly, cfg.ShowRAMOnly, cfg.OutputFormat, &errorsList)
-				} else {
-					namespaces, err := clientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
-					if err != nil {
-						pterm.Error.WithShowLineNumber(true).Println(err)
-						os.Exit(1)
-					}
-					listNodeMetrics(namespaces.Items, clientset, metricsClientset, cfg.ShowCPUOnly, cfg.ShowRAMOnly, cfg.OutputFormat, &errorsList)
-				}
-			} else if cfg.Namespace == "" {
-				namespaces, err := clientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+			if cfg.MultiCluster() {
+				spinner.Success("Initialization done")
+				clusters, errs := collectClusters(cfg)
+				errorsList = append(errorsList, errs...)
+				view, r = "clusters", clustersReport(clusters, cfg.Grouping(), cfg.ShowEfficiency, cfg.Ordering())
+			} else {
+				clientset, metricsClientset, err := connectClients(cfg)
 				if err != nil {
+					spinner.Fail("Initialization error")
 					pterm.Error.WithShowLineNumber(true).Println(err)
 					os.Exit(1)
 				}
-				listNamespaceMetrics(namespaces.Items, clientset, metricsClientset, cfg.OutputFormat, &errorsList)
-			} else {
-				namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: cfg.Namespace}}
-				printNamespaceMetrics(*namespace, clientset, metricsClientset, cfg.OutputFormat, &errorsList)
+
+				spinner.Success("Initialization done")
+
+				if cfg.Watch > 0 {
+					watch(cfg, clientset, metricsClientset)
+					return
+				}
+
+				cluster, collectErrors := collectCluster(cfg, clientset, metricsClientset)
+				errorsList = append(errorsList, collectErrors...)
+				view, r = buildReport(cfg, cluster)
 			}
 
-			if len(errorsList) > 0 {
-				pterm.Warning.Println("Error(s):")
-				for i, err := range errorsList {
-					pterm.Printf("%d. %v\n", i+1, err)
+			if r != nil {
+				renderReport(cfg.OutputFormat, r, errorsList)
+			} else {
+				pterm.Warning.Printf("No pods found in namespace: %s\n", cfg.Namespace)
+				if renderers[cfg.OutputFormat].machineReadable {
+					renderReport(cfg.OutputFormat, &report{Document: newJSONDocument(view, cfg.Namespace)}, errorsList)
 				}
 			}
+
+			// Some renderers embed the errors in their document
+			if !renderers[cfg.OutputFormat].embedsErrors {
+				printErrors(errorsList)
+			}
 		},
 	}
 
-	rootCmd.PersistentFlags().StringVar(&cfg.Kubeconfig, "kubeconfig", cfg.Kubeconfig, "(optional) absolute path to the kubeconfig file")
+	cfg.KubeFlags.AddFlags(rootCmd.PersistentFlags())
+	addCollectionFlags(rootCmd, cfg, "Report on every namespace")
 	rootCmd.Flags().BoolVarP(&cfg.ShowNode, "node", "N", false, "Display resource usage matrix by node")
 	rootCmd.Flags().BoolVarP(&cfg.ShowCPUOnly, "cpu", "c", false, "Show only CPU table (use with -N)")
 	rootCmd.Flags().BoolVarP(&cfg.ShowRAMOnly, "ram", "r", false, "Show only RAM table (use with -N)")
-	rootCmd.Flags().StringVarP(&cfg.OutputFormat, "output", "o", "table", "Output format: table or html")
+	rootCmd.Flags().BoolVarP(&cfg.ShowEfficiency, "efficiency", "e", false, "Add usage/request, request/limit and wasted request columns to the namespaces and pods tables")
+	rootCmd.Flags().BoolVarP(&cfg.ShowWorkloads, "workloads", "W", false, "Sum the pods per workload (Deployment, StatefulSet, CronJob, ...) with the per-replica average")
+	rootCmd.Flags().StringVar(&cfg.SortBy, "sort-by", "", "Order the rows by "+strings.Join(sortKeyNames(), ", ")+" (name by default)")
+	rootCmd.Flags().BoolVar(&cfg.Reverse, "reverse", false, "Reverse the order of --sort-by")
+	rootCmd.Flags().IntVar(&cfg.Top, "top", 0, "Keep the first N rows and sum the others into one row (0 keeps all)")
+	rootCmd.Flags().StringVar(&cfg.GroupBy, "group-by", "", "Replace namespaces (or nodes with -N) by the values of label:<key>, annotation:<key> or node-label:<key>")
+	rootCmd.Flags().StringSliceVar(&cfg.Contexts, "contexts", nil, "Collect several kubeconfig contexts into one multi-cluster report (comma separated)")
+	rootCmd.Flags().StringVar(&cfg.KubeconfigGlob, "kubeconfig-glob", "", "Collect the current context of every kubeconfig file matching the pattern into one multi-cluster report")
+	addSamplingFlags(rootCmd, cfg, "Sample usage over this window and report min/avg/p50/p95/max (e.g. 10m)")
+	rootCmd.Flags().DurationVarP(&cfg.Watch, "watch", "w", 0, "Refresh the tables in place at this interval, highlighting changes (e.g. 10s)")
+
+	rootCmd.AddCommand(newSnapshotCmd(cfg))
+	rootCmd.AddCommand(newRecommendCmd(cfg))
+	rootCmd.AddCommand(newWasteCmd(cfg))
+	rootCmd.AddCommand(newCostCmd(cfg))
+	rootCmd.AddCommand(newCheckCmd(cfg))
+	rootCmd.AddCommand(newMissingCmd(cfg))
+	rootCmd.AddCommand(newServeCmd(cfg))
+
+	// Installed as kubectl-kram, the binary runs as "kubectl kram"
+	if strings.HasPrefix(filepath.Base(os.Args[0]), "kubectl-") {
+		rootCmd.Annotations = map[string]string{cobra.CommandDisplayNameAnnotation: "kubectl kram"}
+	}
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
 }
+
+// newCollector creates a collector configured from the flags
+func newCollector(cfg *Config, clientset kubernetes.Interface, metricsClientset metricsv.Interface) *kram.Collector {
+	c := kram.NewCollector(clientset, metricsClientset)
+	c.WrapCall = suppressKubernetesLogs
+	c.LabelSelector = cfg.LabelSelector
+	c.FieldSelector = cfg.FieldSelector
+	c.NamespaceSelector = cfg.NamespaceSelector
+	c.IncludeNodes = cfg.ShowNode
+	c.ResolveOwners = cfg.ShowWorkloads || cfg.ResolveOwners
+	applyGrouping(c, cfg.Grouping())
+	return c
+}
+
+// applyGrouping makes the collector read the labels the grouping needs
+func applyGrouping(c *kram.Collector, by kram.GroupBy) {
+	switch by.Source {
+	case kram.GroupByLabel, kram.GroupByAnnotation:
+		c.IncludeNamespaceMetadata = true
+	case kram.GroupByNodeLabel:
+		c.IncludeNodes = true
+	}
+}
+
+// addCollectionFlags registers the flags shared by the commands reporting on pods: namespaces, selectors,
+// output format and snapshot. allNamespaces describes -A for the command, e.g. "Check every namespace".
+func addCollectionFlags(cmd *cobra.Command, cfg *Config, allNamespaces string) {
+	cmd.Flags().BoolVarP(&cfg.AllNamespaces, "all-namespaces", "A", false, allNamespaces+" (default when no namespace is given)")
+	addSelectorFlags(cmd, cfg)
+	cmd.Flags().StringVarP(&cfg.OutputFormat, "output", "o", "table", "Output format: "+strings.Join(outputFormats(), ", "))
+}
+
+// addSelectorFlags registers the pod and namespace selectors and the snapshot to read instead of the cluster
+func addSelectorFlags(cmd *cobra.Command, cfg *Config) {
+	cmd.Flags().StringVarP(&cfg.LabelSelector, "selector", "l", "", "Label selector applied to pods and pod metrics (e.g. app=payments)")
+	cmd.Flags().StringVar(&cfg.FieldSelector, "field-selector", "", "Field selector applied to pods (e.g. spec.nodeName=node-3 or status.phase=Running)")
+	cmd.Flags().StringVar(&cfg.NamespaceSelector, "namespace-selector", "", "Label selector restricting the namespaces reported (e.g. team=payments)")
+	cmd.Flags().StringVar(&cfg.FromSnapshot, "from-snapshot", "", "Read metrics from a file written by 'kram snapshot save' instead of the cluster")
+}
+
+// addSamplingFlags registers --duration, described for the command, and --interval
+func addSamplingFlags(cmd *cobra.Command, cfg *Config, duration string) {
+	cmd.Flags().DurationVar(&cfg.Duration, "duration", 0, duration)
+	cmd.Flags().DurationVar(&cfg.Interval, "interval", cfg.Interval, "Time between two usage readings with --duration")
+}
+
+// collectCluster collects the namespaces selected by cfg, showing a progress bar
+func collectCluster(cfg *Config, clientset kubernetes.Interface, metricsClientset metricsv.Interface) (*kram.Cluster, []error) {
+	c := newCollector(cfg, clientset, metricsClientset)
+	namespaces, err := c.NamespaceNames(context.TODO(), cfg.Namespace)
+	if err != nil {
+		pterm.Error.WithShowLineNumber(true).Println(err)
+		os.Exit(1)
+	}
+
+	total := len(namespaces)
+	if cfg.Duration > 0 {
+		total += int(cfg.Duration/cfg.Interval) - 1
+	}
+	bar, _ := pterm.DefaultProgressbar.
+		WithTotal(total).
+		WithTitle("Running").
+		WithRemoveWhenDone().
+		Start()
+	c.Progress = func() { bar.Increment() }
+
+	if cfg.Duration > 0 {
+		bar.UpdateTitle(fmt.Sprintf("Sampling every %s for %s", cfg.Interval, cfg.Duration))
+		return c.Sample(context.TODO(), namespaces, cfg.Duration, cfg.Interval)
+	}
+	return c.Collect(context.TODO(), namespaces)
+}
+
+// buildReport selects the view from the flags; the report is nil when the namespace has no pods
+func buildReport(cfg *Config, cluster *kram.Cluster) (string, *report) {
+	switch {
+	case cfg.ShowWorkloads:
+		return "workloads", workloadsReport(cluster, cfg.Namespace, cfg.Ordering())
+	case cfg.ShowNode && cfg.Namespace != "":
+		return "namespace-nodes", namespaceNodesReport(cluster, cfg.Namespace, cfg.Grouping(), cfg.ShowCPUOnly, cfg.ShowRAMOnly, cfg.Ordering())
+	case cfg.ShowNode:
+		return "nodes", nodesReport(cluster, cfg.Grouping(), cfg.ShowCPUOnly, cfg.ShowRAMOnly, cfg.Ordering())
+	case cfg.Namespace == "":
+		return "namespaces", namespacesReport(cluster, cfg.Grouping(), cfg.ShowEfficiency, cfg.Ordering())
+	default:
+		return "namespace", namespaceReport(cluster, cfg.Namespace, cfg.ShowEfficiency, cfg.Ordering())
+	}
+}
+
+// collectForCommand runs the steps shared by the subcommands reporting on pods: flags validation,
+// connection and collection. Decorations go to stderr when the command writes a document to stdout.
+func collectForCommand(cfg *Config, args []string, documentOnStdout bool, validate func() error) (*kram.Cluster, []error) {
+	if documentOnStdout || renderers[cfg.OutputFormat].machineReadable {
+		pterm.SetDefaultOutput(os.Stderr)
+		cursor.SetTarget(os.Stderr)
+	}
+
+	spinner, _ := pterm.DefaultSpinner.Start("Initialization running")
+
+	if err := cfg.ResolveNamespace(args); err != nil {
+		spinner.Fail("Initialization error")
+		pterm.Error.Println(err)
+		os.Exit(1)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		spinner.Fail("Initialization error")
+		pterm.Error.Println(err)
+		os.Exit(1)
+	}
+
+	if validate != nil {
+		if err := validate(); err != nil {
+			spinner.Fail("Initialization error")
+			pterm.Error.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	clientset, metricsClientset, err := connectClients(cfg)
+	if err != nil {
+		spinner.Fail("Initialization error")
+		pterm.Error.WithShowLineNumber(true).Println(err)
+		os.Exit(1)
+	}
+
+	spinner.Success("Initialization done")
+
+	return collectCluster(cfg, clientset, metricsClientset)
+}
+
+// printErrors lists the collection errors after the report
+func printErrors(errorsList []error) {
+	if len(errorsList) == 0 {
+		return
+	}
+	pterm.Warning.Println("Error(s):")
+	for i, err := range errorsList {
+		pterm.Printf("%d. %v\n", i+1, err)
+	}
+}
@@ -1,574 +1,338 @@
 package main
 
 import (
-	"context"
 	"fmt"
-	"os"
 	"sort"
-	"sync"
 
-	"github.com/pterm/pterm"
-	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
-	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+	"github.com/PaulPowershell/Kram/pkg/kram"
+	"github.com/go-echarts/go-echarts/v2/charts"
 )
 
 // ============================================================
 // METRICS — vue globale (kram -o html)
 // ============================================================
 
-func listNamespaceMetrics(namespaces []corev1.Namespace, clientset *kubernetes.Clientset, metricsClientset *metricsv.Clientset, outputFormat string, errorsList *[]error) {
-	bar, _ := pterm.DefaultProgressbar.
-		WithTotal(len(namespaces)).
-		WithTitle("Running").
-		WithRemoveWhenDone().
-		Start()
-
-	// Pre-allocate slice to avoid repeated allocations
-	podTableData := make([][]string, 0, len(namespaces)+2)
-	podTableData = append(podTableData, []string{"Namespace", "Pods", "CPU Usage", "CPU Request", "CPU Limit", "Mem Usage", "Mem Request", "Mem Limit"})
-
-	type nsRawStats struct {
-		cpuUsage, cpuRequest, cpuLimit int64
-		memUsage, memRequest, memLimit int64
-	}
-	nsRawData := make(map[string]*nsRawStats)
-	var nsOrder []string
-	var totalPods int
-	var totalCPUUsage, totalCPURequest, totalCPULimit int64
-	var totalMemUsage, totalMemRequest, totalMemLimit int64
-
-	// Thread-safe synchronization for parallel processing
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-	wg.Add(len(namespaces))
-
-	for _, namespace := range namespaces {
-		go func(ns corev1.Namespace) {
-			defer wg.Done()
-			defer func() {
-				bar.Increment()
-			}()
-
-			var pods *corev1.PodList
-			err := suppressKubernetesLogs(func() error {
-				var e error
-				pods, e = clientset.CoreV1().Pods(ns.Name).List(context.TODO(), metav1.ListOptions{})
-				return e
-			})
-			if err != nil {
-				mu.Lock()
-				*errorsList = append(*errorsList, err)
-				mu.Unlock()
-				return
-			}
-
-			if len(pods.Items) == 0 {
-				return
-			}
-
-			var nsCPUUsage, nsCPURequest, nsCPULimit int64
-			var nsMemUsage, nsMemRequest, nsMemLimit int64
-
-			// Fetch all metrics for namespace at once (1 API call instead of N)
-			metricsMap := getNamespacePodMetricsMap(context.TODO(), metricsClientset, ns.Name, errorsList, &mu)
-
-			for _, pod := range pods.Items {
-				podMetrics, ok := metricsMap[pod.Name]
-				if !ok {
-					continue
-				}
-
-				// O(1) container metrics lookup instead of O(n*m) double loop
-				containerMetricsMap := getContainerMetricsMap(podMetrics)
-
-				for _, container := range pod.Spec.Containers {
-					containerMetrics, ok := containerMetricsMap[container.Name]
-					if !ok {
-						continue
-					}
-					nsCPUUsage += containerMetrics.Usage.Cpu().MilliValue()
-					nsCPURequest += container.Resources.Requests.Cpu().MilliValue()
-					nsCPULimit += container.Resources.Limits.Cpu().MilliValue()
-					nsMemUsage += containerMetrics.Usage.Memory().Value()
-					nsMemRequest += container.Resources.Requests.Memory().Value()
-					nsMemLimit += container.Resources.Limits.Memory().Value()
-				}
-			}
-
-			// Accumulate locally first, then single lock for all shared state updates
-			mu.Lock()
-			podTableData = append(podTableData, []string{
-				ns.Name,
-				pterm.Sprint(len(pods.Items)),
-				formatCPU(nsCPUUsage),
-				formatCPU(nsCPURequest),
-				formatCPU(nsCPULimit),
-				formatMemory(nsMemUsage),
-				formatMemory(nsMemRequest),
-				formatMemory(nsMemLimit),
-			})
-			totalPods += len(pods.Items)
-			totalCPUUsage += nsCPUUsage
-			totalCPURequest += nsCPURequest
-			totalCPULimit += nsCPULimit
-			totalMemUsage += nsMemUsage
-			totalMemRequest += nsMemRequest
-			totalMemLimit += nsMemLimit
-			nsRawData[ns.Name] = &nsRawStats{
-				cpuUsage: nsCPUUsage, cpuRequest: nsCPURequest, cpuLimit: nsCPULimit,
-				memUsage: nsMemUsage, memRequest: nsMemRequest, memLimit: nsMemLimit,
-			}
-			nsOrder = append(nsOrder, ns.Name)
-			mu.Unlock()
-		}(namespace)
-	}
-
-	wg.Wait()
-
-	podTableData = append(podTableData, []string{
-		"Total", pterm.Sprint(totalPods),
-		formatCPU(totalCPUUsage),
-		formatCPU(totalCPURequest),
-		formatCPU(totalCPULimit),
-		formatMemory(totalMemUsage),
-		formatMemory(totalMemRequest),
-		formatMemory(totalMemLimit),
-	})
-
-	if outputFormat == "html" {
-		xLabels := make([]string, len(nsOrder))
-		cpuUsageVals := make([]float64, len(nsOrder))
-		cpuReqVals := make([]float64, len(nsOrder))
-		cpuLimVals := make([]float64, len(nsOrder))
-		memUsageVals := make([]float64, len(nsOrder))
-		memReqVals := make([]float64, len(nsOrder))
-		memLimVals := make([]float64, len(nsOrder))
-
-		for i, ns := range nsOrder {
-			d := nsRawData[ns]
-			xLabels[i] = ns
-			cpuUsageVals[i] = float64(d.cpuUsage)
-			cpuReqVals[i] = float64(d.cpuRequest)
-			cpuLimVals[i] = float64(d.cpuLimit)
-			memUsageVals[i] = toMiB(d.memUsage)
-			memReqVals[i] = toMiB(d.memRequest)
-			memLimVals[i] = toMiB(d.memLimit)
-		}
-
-		cpuBarChart := newBarChart([]barChartSeries{
-			{name: "Usage", values: cpuUsageVals},
-			{name: "Request", values: cpuReqVals},
-			{name: "Limit", values: cpuLimVals},
-		}, xLabels, "CPU — Usage / Request / Limit — Namespaces", "millicores")
-
-		memBarChart := newBarChart([]barChartSeries{
-			{name: "Usage", values: memUsageVals},
-			{name: "Request", values: memReqVals},
-			{name: "Limit", values: memLimVals},
-		}, xLabels, "Memory — Usage / Request / Limit — Namespaces", "MiB")
-
-		chartHead, chartBody := barBodySnippet(cpuBarChart, memBarChart)
-		renderHTML([]htmlSection{{Title: "Namespaces Resource Metrics", Data: podTableData}},
-			htmlOutputPath("kram-namespaces.html"), chartHead, chartBody)
-	} else {
-		pterm.DefaultTable.WithHeaderRowSeparator("─").WithBoxed().WithHasHeader().WithAlternateRowStyle(alternateStyle).WithData(podTableData).Render()
-	}
+// namespacesSummary holds the namespaces table of a cluster with the raw values behind it
+type namespacesSummary struct {
+	table   [][]string
+	records []jsonNamespace
+	labels  []string
+	values  []kram.Quantities
+	// namespaces counts the namespaces holding pods, whatever the grouping and the top
+	namespaces int
+	pods       kram.PodCounts
+	total      kram.Quantities
 }
 
-// ============================================================
-// METRICS — vue namespace (kram namespace1 -o html)
-// ============================================================
-
-func printNamespaceMetrics(namespace corev1.Namespace, clientset *kubernetes.Clientset, metricsClientset *metricsv.Clientset, outputFormat string, errorsList *[]error) {
-	pods, err := clientset.CoreV1().Pods(namespace.Name).List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		pterm.Error.WithShowLineNumber(true).Println(err)
-		os.Exit(1)
+// summarizeNamespaces builds the namespaces table, with the efficiency columns when requested.
+// With a grouping, rows are the values of the group instead of the namespaces.
+// Rows past the top of the order are summed into a single "Others" row, left out of the records and charts.
+func summarizeNamespaces(cluster *kram.Cluster, by kram.GroupBy, efficiency bool, order kram.Order) namespacesSummary {
+	summary := namespacesSummary{table: make([][]string, 0, len(cluster.Namespaces)+2)}
+	sampled := cluster.Samples > 1
+	summary.table = append(summary.table, withEfficiencyHeader(append(append([]string{groupHeader(by, "Namespace")}, podCountsHeader()...), quantitiesHeader(sampled)...), efficiency))
+	var totalWaste kram.Resources
+	for _, ns := range cluster.Namespaces {
+		if len(ns.Pods) > 0 {
+			summary.namespaces++
+		}
 	}
 
-	if len(pods.Items) == 0 {
-		pterm.Warning.Printf("No pods found in namespace: %s\n", namespace.Name)
-		return
+	var rows []*kram.Group
+	for _, row := range namespaceGroups(cluster, by) {
+		if len(row.Pods) > 0 {
+			rows = append(rows, row)
+		}
 	}
-
-	bar, _ := pterm.DefaultProgressbar.
-		WithTotal(len(pods.Items)).
-		WithTitle("Running").
-		WithRemoveWhenDone().
-		Start()
-
-	type podBarData struct {
-		name                           string
-		cpuUsage, cpuRequest, cpuLimit int64
-		memUsage, memRequest, memLimit int64
+	kept, rest := kram.Arrange(rows, order, groupName, (*kram.Group).Total)
+	if len(rest) > 0 {
+		others := &kram.Group{Name: othersName(len(rest))}
+		for _, row := range rest {
+			others.Pods = append(others.Pods, row.Pods...)
+		}
+		kept = append(kept, others)
 	}
 
-	podTableData := make([][]string, 0, len(pods.Items)*2+2)
-	podTableData = append(podTableData, []string{"Pods", "Container", "CPU Usage", "CPU Request", "CPU Limit", "Mem Usage", "Mem Request", "Mem Limit"})
-
-	var podBarsMap map[string]*podBarData = make(map[string]*podBarData)
-	var totalCPUUsage, totalCPURequest, totalCPULimit int64
-	var totalMemUsage, totalMemRequest, totalMemLimit int64
+	for i, row := range kept {
+		q := row.Total()
+		samples := row.UsageSamples()
 
-	// Fetch all metrics for namespace at once (1 API call instead of N)
-	var localMu sync.Mutex
-	metricsMap := getNamespacePodMetricsMap(context.TODO(), metricsClientset, namespace.Name, errorsList, &localMu)
+		waste := row.Waste()
+		totalWaste.Add(waste)
 
-	for _, pod := range pods.Items {
-		bar.Increment()
+		counts := kram.CountPods(row.Pods)
+		summary.table = append(summary.table, withEfficiencyCells(append(append([]string{row.Name}, formatPodCounts(counts)...), formatSampledQuantities(q, samples)...), q, waste, efficiency))
+		summary.pods.Add(counts)
+		summary.total.Add(q)
 
-		podMetrics, ok := metricsMap[pod.Name]
-		if !ok {
+		if len(rest) > 0 && i == len(kept)-1 {
 			continue
 		}
+		summary.records = append(summary.records, jsonNamespace{Name: row.Name, Pods: len(row.Pods), jsonPodCounts: newJSONPodCounts(counts), jsonResources: withJSONEfficiency(newSampledJSONResources(q, samples), q, waste, efficiency)})
+		summary.labels = append(summary.labels, row.Name)
+		summary.values = append(summary.values, q)
+	}
 
-		// O(1) container spec lookup instead of O(n) loop per container
-		containerSpecMap := getContainerSpecMap(&pod)
+	summary.table = append(summary.table, withEfficiencyCells(append(append([]string{"Total"}, formatPodCounts(summary.pods)...), formatSampledQuantities(summary.total, cluster.UsageSamples())...), summary.total, totalWaste, efficiency))
+	return summary
+}
 
-		for _, containerMetrics := range podMetrics.Containers {
-			containerSpec, ok := containerSpecMap[containerMetrics.Name]
-			if !ok {
-				continue
-			}
+func namespacesReport(cluster *kram.Cluster, by kram.GroupBy, efficiency bool, order kram.Order) *report {
+	summary := summarizeNamespaces(cluster, by, efficiency, order)
 
-			usage := containerMetrics.Usage
-			requests := containerSpec.Resources.Requests
-			limits := containerSpec.Resources.Limits
-
-			cpuUsage := usage.Cpu().MilliValue()
-			cpuRequest := requests.Cpu().MilliValue()
-			cpuLimit := limits.Cpu().MilliValue()
-			memUsage := usage.Memory().Value()
-			memRequest := requests.Memory().Value()
-			memLimit := limits.Memory().Value()
-
-			podTableData = append(podTableData, []string{
-				pod.Name,
-				containerMetrics.Name,
-				formatCPU(cpuUsage),
-				formatCPU(cpuRequest),
-				formatCPU(cpuLimit),
-				formatMemory(memUsage),
-				formatMemory(memRequest),
-				formatMemory(memLimit),
-			})
-
-			totalCPUUsage += cpuUsage
-			totalCPURequest += cpuRequest
-			totalCPULimit += cpuLimit
-			totalMemUsage += memUsage
-			totalMemRequest += memRequest
-			totalMemLimit += memLimit
-
-			// Agréger par pod pour le chart (somme de tous ses containers)
-			if _, ok := podBarsMap[pod.Name]; !ok {
-				podBarsMap[pod.Name] = &podBarData{name: pod.Name}
-			}
-			p := podBarsMap[pod.Name]
-			p.cpuUsage += cpuUsage
-			p.cpuRequest += cpuRequest
-			p.cpuLimit += cpuLimit
-			p.memUsage += memUsage
-			p.memRequest += memRequest
-			p.memLimit += memLimit
-		}
-	}
+	doc := newJSONDocument("namespaces", "")
+	doc.GroupBy = by.String()
+	doc.Namespaces = summary.records
+	doc.Total = newSampledJSONResources(summary.total, cluster.UsageSamples())
 
-	// Convert map to ordered slice for rendering
-	var podBars []podBarData
-	for _, p := range podBarsMap {
-		podBars = append(podBars, *p)
-	}
-
-	podTableData = append(podTableData, []string{
-		"Total", "",
-		formatCPU(totalCPUUsage),
-		formatCPU(totalCPURequest),
-		formatCPU(totalCPULimit),
-		formatMemory(totalMemUsage),
-		formatMemory(totalMemRequest),
-		formatMemory(totalMemLimit),
-	})
-
-	if outputFormat == "html" {
-		xLabels := make([]string, len(podBars))
-		cpuUsageVals := make([]float64, len(podBars))
-		cpuReqVals := make([]float64, len(podBars))
-		cpuLimVals := make([]float64, len(podBars))
-		memUsageVals := make([]float64, len(podBars))
-		memReqVals := make([]float64, len(podBars))
-		memLimVals := make([]float64, len(podBars))
-
-		for i, p := range podBars {
-			xLabels[i] = p.name
-			cpuUsageVals[i] = float64(p.cpuUsage)
-			cpuReqVals[i] = float64(p.cpuRequest)
-			cpuLimVals[i] = float64(p.cpuLimit)
-			memUsageVals[i] = toMiB(p.memUsage)
-			memReqVals[i] = toMiB(p.memRequest)
-			memLimVals[i] = toMiB(p.memLimit)
-		}
+	cpuBarChart, memBarChart := quantitiesBarCharts(summary.labels, summary.values,
+		"CPU — Usage / Request / Limit — "+groupHeader(by, "Namespaces"),
+		"Memory — Usage / Request / Limit — "+groupHeader(by, "Namespaces"))
 
-		cpuBarChart := newBarChart([]barChartSeries{
-			{name: "Usage", values: cpuUsageVals},
-			{name: "Request", values: cpuReqVals},
-			{name: "Limit", values: cpuLimVals},
-		}, xLabels, fmt.Sprintf("CPU — Usage / Request / Limit — %s", namespace.Name), "millicores")
-
-		memBarChart := newBarChart([]barChartSeries{
-			{name: "Usage", values: memUsageVals},
-			{name: "Request", values: memReqVals},
-			{name: "Limit", values: memLimVals},
-		}, xLabels, fmt.Sprintf("Memory — Usage / Request / Limit — %s", namespace.Name), "MiB")
-
-		chartHead, chartBody := barBodySnippet(cpuBarChart, memBarChart)
-		renderHTML([]htmlSection{
-			{Title: fmt.Sprintf("Metrics for Namespace: %s", namespace.Name), Data: podTableData},
-		}, htmlOutputPath(fmt.Sprintf("kram-%s.html", namespace.Name)), chartHead, chartBody)
-	} else {
-		pterm.Printf("Metrics for Namespace: %s\n", namespace.Name)
-		pterm.DefaultTable.WithHeaderRowSeparator("─").WithBoxed().WithHasHeader().WithAlternateRowStyle(alternateStyle).WithData(podTableData).Render()
+	return &report{
+		Name:     "kram-namespaces",
+		Sections: []reportSection{{Title: groupHeader(by, "Namespaces") + " Resource Metrics", Data: summary.table}},
+		Charts:   []*charts.Bar{cpuBarChart, memBarChart},
+		Document: doc,
 	}
 }
 
 // ============================================================
-// METRICS — vue nodes globale (kram -N -o html)
+// METRICS — vue namespace (kram namespace1 -o html)
 // ============================================================
 
-func listNodeMetrics(namespaces []corev1.Namespace, clientset *kubernetes.Clientset, metricsClientset *metricsv.Clientset, onlyCPU bool, onlyRAM bool, outputFormat string, errorsList *[]error) {
-	type resourceStats struct {
-		memUsage, memRequest, memLimit int64
-		cpuUsage, cpuRequest, cpuLimit int64
-	}
-	nsNodeStats := make(map[string]map[string]*resourceStats)
-	nodeSet := make(map[string]struct{})
-
-	podsByNamespace := make(map[string][]corev1.Pod)
-	totalPods := 0
-	for _, namespace := range namespaces {
-		var pods *corev1.PodList
-		err := suppressKubernetesLogs(func() error {
-			var e error
-			pods, e = clientset.CoreV1().Pods(namespace.Name).List(context.TODO(), metav1.ListOptions{})
-			return e
-		})
-		if err != nil {
-			*errorsList = append(*errorsList, err)
-			continue
-		}
-		if len(pods.Items) == 0 {
-			continue
-		}
-		podsByNamespace[namespace.Name] = pods.Items
-		totalPods += len(pods.Items)
+// namespaceReport returns nil when the namespace has no pods
+func namespaceReport(cluster *kram.Cluster, name string, efficiency bool, order kram.Order) *report {
+	ns := cluster.Namespace(name)
+	if ns == nil || len(ns.Pods) == 0 {
+		return nil
 	}
 
-	bar, _ := pterm.DefaultProgressbar.
-		WithTotal(totalPods).
-		WithTitle("Running").
-		WithRemoveWhenDone().
-		Start()
-
-	// Thread-safe synchronization for parallel processing
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-	wg.Add(len(podsByNamespace))
+	podTableData := make([][]string, 0, len(ns.Pods)*2+2)
+	podTableData = append(podTableData, withEfficiencyHeader(append([]string{"Pods", "Phase", "Container", "Kind"}, quantitiesHeader(cluster.Samples > 1)...), efficiency))
 
-	for namespaceName, pods := range podsByNamespace {
-		go func(ns string, nsPods []corev1.Pod) {
-			defer wg.Done()
+	doc := newJSONDocument("namespace", name)
+	var total kram.Quantities
+	var totalSamples []kram.Resources
+	var totalWaste kram.Resources
+	var xLabels []string
+	var values []kram.Quantities
 
-			nsLocalStats := make(map[string]*resourceStats)
+	pods, rest := kram.Arrange(ns.Pods, order, podName, (*kram.Pod).Total)
 
-			// Fetch all metrics for namespace at once (1 API call instead of N)
-			metricsMap := getNamespacePodMetricsMap(context.TODO(), metricsClientset, ns, errorsList, &mu)
-
-			for _, pod := range nsPods {
-				bar.Increment()
-
-				nodeName := pod.Spec.NodeName
-
-				if _, ok := nsLocalStats[nodeName]; !ok {
-					nsLocalStats[nodeName] = &resourceStats{}
-				}
-				stats := nsLocalStats[nodeName]
+	for _, pod := range pods {
+		jsonPodEntry := jsonPod{Name: pod.Name, Namespace: pod.Namespace, Node: pod.NodeName, Phase: pod.Phase}
+		for _, container := range pod.Containers {
+			waste := container.Waste()
+			podTableData = append(podTableData, withEfficiencyCells(append([]string{pod.Name, pod.Phase, container.Name, string(container.Kind)}, formatSampledQuantities(container.Quantities, container.Samples)...), container.Quantities, waste, efficiency))
+			jsonPodEntry.Containers = append(jsonPodEntry.Containers, jsonContainer{Name: container.Name, Kind: string(container.Kind), jsonResources: withJSONEfficiency(newSampledJSONResources(container.Quantities, container.Samples), container.Quantities, waste, efficiency)})
+		}
+		// The RuntimeClass overhead is requested by the pod on top of its containers
+		if pod.Overhead != (kram.Resources{}) {
+			overhead := kram.Quantities{Request: pod.Overhead, Set: kram.Specified{CPURequest: true, MemoryRequest: true}}
+			podTableData = append(podTableData, withEfficiencyCells(append([]string{pod.Name, pod.Phase, "", "overhead"}, formatQuantities(overhead)...), overhead, kram.Resources{}, efficiency))
+		}
 
-				podMetrics, ok := metricsMap[pod.Name]
-				if !ok {
-					continue
-				}
+		podTotal := pod.Total()
+		podSamples := pod.UsageSamples()
+		jsonPodEntry.jsonResources = withJSONEfficiency(newSampledJSONResources(podTotal, podSamples), podTotal, pod.Waste(), efficiency)
+		doc.Pods = append(doc.Pods, jsonPodEntry)
+		total.Add(podTotal)
+		totalWaste.Add(pod.Waste())
+		totalSamples = kram.AddSamples(totalSamples, podSamples)
+
+		// Agréger par pod pour le chart (somme de tous ses containers)
+		if len(pod.Containers) > 0 {
+			xLabels = append(xLabels, pod.Name)
+			values = append(values, podTotal)
+		}
+	}
 
-				containerSpecMap := getContainerSpecMap(&pod)
+	if len(rest) > 0 {
+		var others kram.Quantities
+		var othersSamples []kram.Resources
+		var othersWaste kram.Resources
+		for _, pod := range rest {
+			others.Add(pod.Total())
+			othersWaste.Add(pod.Waste())
+			othersSamples = kram.AddSamples(othersSamples, pod.UsageSamples())
+		}
+		podTableData = append(podTableData, withEfficiencyCells(append([]string{othersName(len(rest)), "", "", ""}, formatSampledQuantities(others, othersSamples)...), others, othersWaste, efficiency))
+		total.Add(others)
+		totalWaste.Add(othersWaste)
+		totalSamples = kram.AddSamples(totalSamples, othersSamples)
+	}
 
-				for _, containerMetrics := range podMetrics.Containers {
-					stats.memUsage += containerMetrics.Usage.Memory().Value()
-					stats.cpuUsage += containerMetrics.Usage.Cpu().MilliValue()
+	podTableData = append(podTableData, withEfficiencyCells(append([]string{"Total", "", "", ""}, formatSampledQuantities(total, totalSamples)...), total, totalWaste, efficiency))
+	doc.Total = newSampledJSONResources(total, totalSamples)
 
-					containerSpec, ok := containerSpecMap[containerMetrics.Name]
-					if ok {
-						stats.memRequest += containerSpec.Resources.Requests.Memory().Value()
-						stats.memLimit += containerSpec.Resources.Limits.Memory().Value()
-						stats.cpuRequest += containerSpec.Resources.Requests.Cpu().MilliValue()
-						stats.cpuLimit += containerSpec.Resources.Limits.Cpu().MilliValue()
-					}
-				}
-			}
+	cpuBarChart, memBarChart := quantitiesBarCharts(xLabels, values,
+		fmt.Sprintf("CPU — Usage / Request / Limit — %s", name),
+		fmt.Sprintf("Memory — Usage / Request / Limit — %s", name))
 
-			mu.Lock()
-			nsNodeStats[ns] = nsLocalStats
-			for nodeName := range nsLocalStats {
-				nodeSet[nodeName] = struct{}{}
-			}
-			mu.Unlock()
-		}(namespaceName, pods)
+	return &report{
+		Name:     fmt.Sprintf("kram-%s", name),
+		Sections: []reportSection{{Title: fmt.Sprintf("Metrics for Namespace: %s", name), Data: podTableData}},
+		Charts:   []*charts.Bar{cpuBarChart, memBarChart},
+		Document: doc,
 	}
+}
 
-	wg.Wait()
+// ============================================================
+// METRICS — vue nodes globale (kram -N -o html)
+// ============================================================
 
-	nodes := make([]string, 0, len(nodeSet))
-	for node := range nodeSet {
-		nodes = append(nodes, node)
+// nodesReport crosses namespaces with nodes, or with the values of the group when one is given
+func nodesReport(cluster *kram.Cluster, by kram.GroupBy, onlyCPU bool, onlyRAM bool, order kram.Order) *report {
+	columnOf := nodeColumn(cluster, by)
+	var rows []*kram.Namespace
+	for _, ns := range cluster.Namespaces {
+		if len(ns.Pods) > 0 {
+			rows = append(rows, ns)
+		}
 	}
-	sort.Strings(nodes)
-
-	nsNames := make([]string, 0, len(nsNodeStats))
-	for ns := range nsNodeStats {
-		nsNames = append(nsNames, ns)
+	rows, rest := kram.Arrange(rows, order, namespaceName, (*kram.Namespace).Total)
+	// The namespaces past --top share a single row, left out of the document and the charts
+	var others *kram.Namespace
+	if len(rest) > 0 {
+		others = &kram.Namespace{Name: othersName(len(rest))}
+		for _, ns := range rest {
+			others.Pods = append(others.Pods, ns.Pods...)
+		}
+		rows = append(rows, others)
 	}
-	sort.Strings(nsNames)
 
-	memHeader := []string{"Namespace"}
-	for _, node := range nodes {
-		memHeader = append(memHeader, shortNodeName(node))
-	}
-	memTableData := [][]string{memHeader}
-	for _, ns := range nsNames {
-		row := []string{ns}
-		for _, node := range nodes {
-			if stats, ok := nsNodeStats[ns][node]; ok {
-				row = append(row, fmt.Sprintf("%.1f/%.1f/%.1f MiB",
-					toMiB(stats.memUsage), toMiB(stats.memRequest), toMiB(stats.memLimit),
-				))
-			} else {
-				row = append(row, "-")
-			}
+	nsNodeStats := make(map[string]map[string]kram.Quantities)
+	var nsNames []string
+	for _, ns := range rows {
+		byNode := make(map[string]kram.Quantities)
+		for _, n := range kram.AggregateBy(ns.Pods, columnOf) {
+			byNode[n.Name] = n.Quantities
 		}
-		memTableData = append(memTableData, row)
+		nsNodeStats[ns.Name] = byNode
+		nsNames = append(nsNames, ns.Name)
+	}
+
+	nodeTotals := kram.AggregateBy(cluster.Pods(), columnOf)
+	nodes := make([]string, len(nodeTotals))
+	for i, n := range nodeTotals {
+		nodes[i] = n.Name
 	}
 
-	cpuHeader := []string{"Namespace"}
+	header := []string{"Namespace"}
 	for _, node := range nodes {
-		cpuHeader = append(cpuHeader, shortNodeName(node))
+		header = append(header, nodeColumnLabel(node, by))
 	}
-	cpuTableData := [][]string{cpuHeader}
+	memTableData := [][]string{header}
+	cpuTableData := [][]string{header}
+
+	doc := newJSONDocument("nodes", "")
+	doc.GroupBy = by.String()
 	for _, ns := range nsNames {
-		row := []string{ns}
+		memRow := []string{ns}
+		cpuRow := []string{ns}
+		entry := jsonNamespace{Name: ns}
+		var nsTotal kram.Quantities
 		for _, node := range nodes {
-			if stats, ok := nsNodeStats[ns][node]; ok {
-				row = append(row, fmt.Sprintf("%dm/%dm/%dm",
-					stats.cpuUsage, stats.cpuRequest, stats.cpuLimit,
-				))
-			} else {
-				row = append(row, "-")
+			stats, ok := nsNodeStats[ns][node]
+			if !ok {
+				memRow = append(memRow, Unset)
+				cpuRow = append(cpuRow, Unset)
+				continue
 			}
+			memRow = append(memRow, formatMemoryCell(stats))
+			cpuRow = append(cpuRow, formatCPUCell(stats))
+			entry.Nodes = append(entry.Nodes, jsonNode{Name: node, jsonResources: newJSONResources(stats)})
+			nsTotal.Add(stats)
 		}
-		cpuTableData = append(cpuTableData, row)
+		memTableData = append(memTableData, memRow)
+		cpuTableData = append(cpuTableData, cpuRow)
+		if others != nil && ns == others.Name {
+			continue
+		}
+		entry.jsonResources = newJSONResources(nsTotal)
+		doc.Namespaces = append(doc.Namespaces, entry)
 	}
 
 	memTotalRow := []string{"Total"}
 	cpuTotalRow := []string{"Total"}
-	for _, node := range nodes {
-		var memUsage, memRequest, memLimit int64
-		var cpuUsage, cpuRequest, cpuLimit int64
-		for _, ns := range nsNames {
-			if stats, ok := nsNodeStats[ns][node]; ok {
-				memUsage += stats.memUsage
-				memRequest += stats.memRequest
-				memLimit += stats.memLimit
-				cpuUsage += stats.cpuUsage
-				cpuRequest += stats.cpuRequest
-				cpuLimit += stats.cpuLimit
-			}
-		}
-		memTotalRow = append(memTotalRow, fmt.Sprintf("%.1f/%.1f/%.1f MiB",
-			toMiB(memUsage), toMiB(memRequest), toMiB(memLimit),
-		))
-		cpuTotalRow = append(cpuTotalRow, fmt.Sprintf("%dm/%dm/%dm",
-			cpuUsage, cpuRequest, cpuLimit,
-		))
+	for _, n := range nodeTotals {
+		memTotalRow = append(memTotalRow, formatMemoryCell(n.Quantities))
+		cpuTotalRow = append(cpuTotalRow, formatCPUCell(n.Quantities))
+		doc.Nodes = append(doc.Nodes, newJSONNode(n, groupNode(cluster, n.Name, by)))
 	}
 	memTableData = append(memTableData, memTotalRow)
 	cpuTableData = append(cpuTableData, cpuTotalRow)
+	doc.Total = newJSONResources(cluster.Total())
 
-	showMem := !onlyCPU
-	showCPU := !onlyRAM
-
-	if outputFormat == "html" {
-		var sections []htmlSection
-		if showMem {
-			sections = append(sections, htmlSection{Title: "Memory Usage / Request / Limit", Data: memTableData})
-		}
-		if showCPU {
-			sections = append(sections, htmlSection{Title: "CPU Usage / Request / Limit", Data: cpuTableData})
-		}
+	titleSuffix := ""
+	if !by.IsZero() {
+		titleSuffix = " — by " + by.String()
+	}
+	var sections []reportSection
+	if !onlyCPU {
+		sections = append(sections, reportSection{Title: "Memory Usage / Request / Limit" + titleSuffix, Data: memTableData})
+	}
+	if !onlyRAM {
+		sections = append(sections, reportSection{Title: "CPU Usage / Request / Limit" + titleSuffix, Data: cpuTableData})
+	}
+	if by.IsZero() {
+		sections = append(sections, nodeCapacitySections(cluster, nodeTotals, true, onlyCPU, onlyRAM, "")...)
+	}
 
-		type nsSortEntry struct {
-			name     string
-			memUsage int64
+	type nsSortEntry struct {
+		name     string
+		memUsage int64
+	}
+	var nsSorted []nsSortEntry
+	for _, ns := range nsNames {
+		if others != nil && ns == others.Name {
+			continue
 		}
-		var nsSorted []nsSortEntry
-		for _, ns := range nsNames {
-			var total int64
-			for _, stats := range nsNodeStats[ns] {
-				total += stats.memUsage
-			}
-			nsSorted = append(nsSorted, nsSortEntry{ns, total})
+		var total int64
+		for _, stats := range nsNodeStats[ns] {
+			total += stats.Usage.Memory
 		}
-		sort.Slice(nsSorted, func(i, j int) bool {
+		nsSorted = append(nsSorted, nsSortEntry{ns, total})
+	}
+	// Without --sort-by the chart shows the namespaces using the most memory, otherwise the first rows
+	if order.IsDefault() {
+		sort.SliceStable(nsSorted, func(i, j int) bool {
 			return nsSorted[i].memUsage > nsSorted[j].memUsage
 		})
-		if len(nsSorted) > maxBarSeries {
-			nsSorted = nsSorted[:maxBarSeries]
-		}
+	}
+	if len(nsSorted) > maxBarSeries {
+		nsSorted = nsSorted[:maxBarSeries]
+	}
 
-		xLabels := make([]string, len(nodes))
-		for i, node := range nodes {
-			xLabels[i] = shortNodeName(node)
-		}
+	xLabels := make([]string, len(nodes))
+	for i, node := range nodes {
+		xLabels[i] = nodeColumnLabel(node, by)
+	}
 
-		var memBarSeries, cpuBarSeries []barChartSeries
-		for _, entry := range nsSorted {
-			ns := entry.name
-			memVals := make([]float64, len(nodes))
-			cpuVals := make([]float64, len(nodes))
-			for i, node := range nodes {
-				if stats, ok := nsNodeStats[ns][node]; ok {
-					memVals[i] = toMiB(stats.memUsage)
-					cpuVals[i] = float64(stats.cpuUsage)
-				}
+	var memBarSeries, cpuBarSeries []barChartSeries
+	for _, entry := range nsSorted {
+		ns := entry.name
+		memVals := make([]float64, len(nodes))
+		cpuVals := make([]float64, len(nodes))
+		for i, node := range nodes {
+			if stats, ok := nsNodeStats[ns][node]; ok {
+				memVals[i] = toMiB(stats.Usage.Memory)
+				cpuVals[i] = float64(stats.Usage.CPU)
 			}
-			memBarSeries = append(memBarSeries, barChartSeries{name: ns, values: memVals})
-			cpuBarSeries = append(cpuBarSeries, barChartSeries{name: ns, values: cpuVals})
 		}
+		memBarSeries = append(memBarSeries, barChartSeries{name: ns, values: memVals})
+		cpuBarSeries = append(cpuBarSeries, barChartSeries{name: ns, values: cpuVals})
+	}
 
-		memBarChart := newBarChart(memBarSeries, xLabels, "Memory usage across nodes — Top namespaces", "MiB")
-		cpuBarChart := newBarChart(cpuBarSeries, xLabels, "CPU usage across nodes — Top namespaces", "millicores")
-		chartHead, chartBody := barBodySnippet(memBarChart, cpuBarChart)
-		renderHTML(sections, htmlOutputPath("kram-nodes.html"), chartHead, chartBody)
-	} else {
-		if showMem {
-			pterm.Printf("Memory Usage / Request / Limit\n")
-			pterm.DefaultTable.WithHeaderRowSeparator("─").WithBoxed().WithHasHeader().WithAlternateRowStyle(alternateStyle).WithData(memTableData).Render()
-		}
-		if showCPU {
-			if showMem {
-				pterm.Printf("\n")
-			}
-			pterm.Printf("CPU Usage / Request / Limit\n")
-			pterm.DefaultTable.WithHeaderRowSeparator("─").WithBoxed().WithHasHeader().WithAlternateRowStyle(alternateStyle).WithData(cpuTableData).Render()
-		}
+	memBarChart := newBarChart(memBarSeries, xLabels, "Memory usage across nodes — Top namespaces", "MiB")
+	cpuBarChart := newBarChart(cpuBarSeries, xLabels, "CPU usage across nodes — Top namespaces", "millicores")
+
+	return &report{
+		Name:     "kram-nodes",
+		Sections: sections,
+		Charts:   []*charts.Bar{memBarChart, cpuBarChart},
+		Document: doc,
 	}
 }
 
@@ -576,197 +340,241 @@ func listNodeMetrics(namespaces []corev1.Namespace, clientset *kubernetes.Client
 // METRICS — vue namespace x nodes (kram namespace1 -N -o html)
 // ============================================================
 
-func listPodNodeMetrics(namespace corev1.Namespace, clientset *kubernetes.Clientset, metricsClientset *metricsv.Clientset, onlyCPU bool, onlyRAM bool, outputFormat string, errorsList *[]error) {
-	var pods *corev1.PodList
-	err := suppressKubernetesLogs(func() error {
-		var e error
-		pods, e = clientset.CoreV1().Pods(namespace.Name).List(context.TODO(), metav1.ListOptions{})
-		return e
-	})
-	if err != nil {
-		pterm.Error.WithShowLineNumber(true).Println(err)
-		os.Exit(1)
+// namespaceNodesReport returns nil when the namespace has no pods
+func namespaceNodesReport(cluster *kram.Cluster, name string, by kram.GroupBy, onlyCPU bool, onlyRAM bool, order kram.Order) *report {
+	ns := cluster.Namespace(name)
+	if ns == nil || len(ns.Pods) == 0 {
+		return nil
 	}
 
-	if len(pods.Items) == 0 {
-		pterm.Warning.Printf("No pods found in namespace: %s\n", namespace.Name)
-		return
+	columnOf := nodeColumn(cluster, by)
+	nodeTotals := kram.AggregateBy(ns.Pods, columnOf)
+
+	header := []string{"Pod"}
+	for _, n := range nodeTotals {
+		header = append(header, nodeColumnLabel(n.Name, by))
+	}
+	memTableData := [][]string{header}
+	cpuTableData := [][]string{header}
+
+	doc := newJSONDocument("namespace-nodes", name)
+	doc.GroupBy = by.String()
+	pods, rest := kram.Arrange(ns.Pods, order, podName, (*kram.Pod).Total)
+	for _, pod := range pods {
+		stats := pod.Total()
+		memRow := []string{pod.Name}
+		cpuRow := []string{pod.Name}
+		for _, n := range nodeTotals {
+			if columnOf(pod) == n.Name {
+				memRow = append(memRow, formatMemoryCell(stats))
+				cpuRow = append(cpuRow, formatCPUCell(stats))
+			} else {
+				memRow = append(memRow, Unset)
+				cpuRow = append(cpuRow, Unset)
+			}
+		}
+		memTableData = append(memTableData, memRow)
+		cpuTableData = append(cpuTableData, cpuRow)
+		doc.Pods = append(doc.Pods, jsonPod{Name: pod.Name, Namespace: pod.Namespace, Node: pod.NodeName, jsonResources: newJSONResources(stats)})
+	}
+	if len(rest) > 0 {
+		othersByColumn := make(map[string]kram.Quantities)
+		for _, n := range kram.AggregateBy(rest, columnOf) {
+			othersByColumn[n.Name] = n.Quantities
+		}
+		memRow := []string{othersName(len(rest))}
+		cpuRow := []string{othersName(len(rest))}
+		for _, n := range nodeTotals {
+			if stats, ok := othersByColumn[n.Name]; ok {
+				memRow = append(memRow, formatMemoryCell(stats))
+				cpuRow = append(cpuRow, formatCPUCell(stats))
+			} else {
+				memRow = append(memRow, Unset)
+				cpuRow = append(cpuRow, Unset)
+			}
+		}
+		memTableData = append(memTableData, memRow)
+		cpuTableData = append(cpuTableData, cpuRow)
 	}
 
-	bar, _ := pterm.DefaultProgressbar.
-		WithTotal(len(pods.Items)).
-		WithTitle("Running").
-		WithRemoveWhenDone().
-		Start()
+	memTotalRow := []string{"Total"}
+	cpuTotalRow := []string{"Total"}
+	xLabels := make([]string, len(nodeTotals))
+	values := make([]kram.Quantities, len(nodeTotals))
+	for i, n := range nodeTotals {
+		memTotalRow = append(memTotalRow, formatMemoryCell(n.Quantities))
+		cpuTotalRow = append(cpuTotalRow, formatCPUCell(n.Quantities))
+		doc.Nodes = append(doc.Nodes, newJSONNode(n, groupNode(cluster, n.Name, by)))
+		xLabels[i] = nodeColumnLabel(n.Name, by)
+		values[i] = n.Quantities
+	}
+	memTableData = append(memTableData, memTotalRow)
+	cpuTableData = append(cpuTableData, cpuTotalRow)
+	doc.Total = newJSONResources(ns.Total())
 
-	type podStats struct {
-		nodeName                       string
-		memUsage, memRequest, memLimit int64
-		cpuUsage, cpuRequest, cpuLimit int64
+	titleSuffix := ""
+	if !by.IsZero() {
+		titleSuffix = " by " + by.String()
+	}
+	var sections []reportSection
+	if !onlyCPU {
+		sections = append(sections, reportSection{Title: fmt.Sprintf("Memory Usage / Request / Limit — %s%s", name, titleSuffix), Data: memTableData})
+	}
+	if !onlyRAM {
+		sections = append(sections, reportSection{Title: fmt.Sprintf("CPU Usage / Request / Limit — %s%s", name, titleSuffix), Data: cpuTableData})
+	}
+	if by.IsZero() {
+		sections = append(sections, nodeCapacitySections(cluster, nodeTotals, false, onlyCPU, onlyRAM, fmt.Sprintf(" — share of %s", name))...)
 	}
 
-	nodeSet := make(map[string]struct{})
-	var podStatsList []podStats
-	var podNames []string
+	cpuBarChart, memBarChart := quantitiesBarCharts(xLabels, values,
+		fmt.Sprintf("CPU across nodes — %s", name),
+		fmt.Sprintf("Memory across nodes — %s", name))
 
-	// Fetch all metrics for namespace at once (1 API call instead of N)
-	var localMu sync.Mutex
-	metricsMap := getNamespacePodMetricsMap(context.TODO(), metricsClientset, namespace.Name, errorsList, &localMu)
+	return &report{
+		Name:     fmt.Sprintf("kram-%s-nodes", name),
+		Sections: sections,
+		Charts:   []*charts.Bar{memBarChart, cpuBarChart},
+		Document: doc,
+	}
+}
 
-	for _, pod := range pods.Items {
-		bar.Increment()
+// ============================================================
+// METRICS — capacité des nodes (kram -N, kram namespace1 -N)
+// ============================================================
 
-		nodeName := pod.Spec.NodeName
-		nodeSet[nodeName] = struct{}{}
+// nodeCapacitySections compares pod quantities with the allocatable of each node.
+// With nodeUsage the usage column is the whole node usage from metrics-server,
+// otherwise it is the usage of the given pods. Nothing is returned when nodes were not collected.
+func nodeCapacitySections(cluster *kram.Cluster, nodeTotals []kram.NodeQuantities, nodeUsage bool, onlyCPU bool, onlyRAM bool, titleSuffix string) []reportSection {
+	if len(cluster.Nodes) == 0 {
+		return nil
+	}
 
-		stats := podStats{nodeName: nodeName}
+	header := []string{"Node", "Capacity", "Allocatable", "Usage", "Usage %", "Request", "Request %", "Limit", "Limit %"}
+	memTableData := [][]string{header}
+	cpuTableData := [][]string{header}
 
-		podMetrics, ok := metricsMap[pod.Name]
-		if !ok {
+	var capacity, allocatable kram.Resources
+	var total kram.Quantities
+	for _, n := range nodeTotals {
+		node := cluster.Node(n.Name)
+		if node == nil {
+			// Pending pods or nodes deleted since the pods were listed
 			continue
 		}
-
-		containerSpecMap := getContainerSpecMap(&pod)
-
-		for _, containerMetrics := range podMetrics.Containers {
-			stats.memUsage += containerMetrics.Usage.Memory().Value()
-			stats.cpuUsage += containerMetrics.Usage.Cpu().MilliValue()
-
-			containerSpec, ok := containerSpecMap[containerMetrics.Name]
-			if ok {
-				stats.memRequest += containerSpec.Resources.Requests.Memory().Value()
-				stats.memLimit += containerSpec.Resources.Limits.Memory().Value()
-				stats.cpuRequest += containerSpec.Resources.Requests.Cpu().MilliValue()
-				stats.cpuLimit += containerSpec.Resources.Limits.Cpu().MilliValue()
-			}
+		q := n.Quantities
+		hasUsage := true
+		if nodeUsage {
+			q.Usage, hasUsage = node.Usage, node.HasMetrics
 		}
+		memTableData = append(memTableData, nodeCapacityRow(shortNodeName(n.Name), node.Capacity.Memory, node.Allocatable.Memory, q.Usage.Memory, q.Request.Memory, q.Limit.Memory, hasUsage, q.Set.MemoryRequest, q.Set.MemoryLimit, formatMemory))
+		cpuTableData = append(cpuTableData, nodeCapacityRow(shortNodeName(n.Name), node.Capacity.CPU, node.Allocatable.CPU, q.Usage.CPU, q.Request.CPU, q.Limit.CPU, hasUsage, q.Set.CPURequest, q.Set.CPULimit, formatCPU))
 
-		podStatsList = append(podStatsList, stats)
-		podNames = append(podNames, pod.Name)
+		capacity.Add(node.Capacity)
+		allocatable.Add(node.Allocatable)
+		total.Add(q)
 	}
+	memTableData = append(memTableData, nodeCapacityRow("Total", capacity.Memory, allocatable.Memory, total.Usage.Memory, total.Request.Memory, total.Limit.Memory, true, total.Set.MemoryRequest, total.Set.MemoryLimit, formatMemory))
+	cpuTableData = append(cpuTableData, nodeCapacityRow("Total", capacity.CPU, allocatable.CPU, total.Usage.CPU, total.Request.CPU, total.Limit.CPU, true, total.Set.CPURequest, total.Set.CPULimit, formatCPU))
 
-	nodes := make([]string, 0, len(nodeSet))
-	for node := range nodeSet {
-		nodes = append(nodes, node)
+	var sections []reportSection
+	if !onlyCPU {
+		sections = append(sections, reportSection{Title: "Node Memory — Capacity / Allocatable" + titleSuffix, Data: memTableData})
 	}
-	sort.Strings(nodes)
+	if !onlyRAM {
+		sections = append(sections, reportSection{Title: "Node CPU — Capacity / Allocatable" + titleSuffix, Data: cpuTableData})
+	}
+	return sections
+}
 
-	type nodeTotals struct {
-		memUsage, memRequest, memLimit int64
-		cpuUsage, cpuRequest, cpuLimit int64
+// nodeCapacityRow formats one resource of a node, percentages being relative to allocatable;
+// a request or limit no pod of the node sets is rendered Unset
+func nodeCapacityRow(name string, capacity, allocatable, usage, request, limit int64, hasUsage, requestSet, limitSet bool, format func(int64) string) []string {
+	usageCell, usagePercent := Unset, Unset
+	if hasUsage {
+		usageCell, usagePercent = format(usage), formatPercent(usage, allocatable)
 	}
-	totals := make(map[string]*nodeTotals)
-	for _, node := range nodes {
-		totals[node] = &nodeTotals{}
+	requestCell, requestPercent := Unset, Unset
+	if requestSet {
+		requestCell, requestPercent = format(request), formatPercent(request, allocatable)
 	}
+	limitCell, limitPercent := Unset, Unset
+	if limitSet {
+		limitCell, limitPercent = format(limit), formatPercent(limit, allocatable)
+	}
+	return []string{
+		name,
+		format(capacity),
+		format(allocatable),
+		usageCell,
+		usagePercent,
+		requestCell,
+		requestPercent,
+		limitCell,
+		limitPercent,
+	}
+}
 
-	memHeader := []string{"Pod"}
-	cpuHeader := []string{"Pod"}
-	for _, node := range nodes {
-		memHeader = append(memHeader, shortNodeName(node))
-		cpuHeader = append(cpuHeader, shortNodeName(node))
+// ============================================================
+// METRICS — regroupement (kram --group-by label:team)
+// ============================================================
+
+// namespaceGroups returns the namespaces as groups, or the values of by when set
+func namespaceGroups(cluster *kram.Cluster, by kram.GroupBy) []*kram.Group {
+	if !by.IsZero() {
+		return cluster.Groups(by)
+	}
+	groups := make([]*kram.Group, len(cluster.Namespaces))
+	for i, ns := range cluster.Namespaces {
+		groups[i] = &kram.Group{Name: ns.Name, Pods: ns.Pods}
 	}
+	return groups
+}
 
-	memTableData := [][]string{memHeader}
-	cpuTableData := [][]string{cpuHeader}
+// nodeColumn returns the column of a pod in the node views: its node, or its value of by when set
+func nodeColumn(cluster *kram.Cluster, by kram.GroupBy) func(*kram.Pod) string {
+	if by.IsZero() {
+		return (*kram.Pod).ScheduledOn
+	}
+	return cluster.GroupOf(by)
+}
 
-	for i, stats := range podStatsList {
-		memRow := []string{podNames[i]}
-		cpuRow := []string{podNames[i]}
+// nodeColumnLabel shortens node names; group values and Unscheduled are kept as they are
+func nodeColumnLabel(name string, by kram.GroupBy) string {
+	if by.IsZero() && name != kram.Unscheduled {
+		return shortNodeName(name)
+	}
+	return name
+}
 
-		for _, node := range nodes {
-			if stats.nodeName == node {
-				memRow = append(memRow, fmt.Sprintf("%.1f/%.1f/%.1f MiB",
-					toMiB(stats.memUsage), toMiB(stats.memRequest), toMiB(stats.memLimit),
-				))
-				cpuRow = append(cpuRow, fmt.Sprintf("%dm/%dm/%dm",
-					stats.cpuUsage, stats.cpuRequest, stats.cpuLimit,
-				))
-				totals[node].memUsage += stats.memUsage
-				totals[node].memRequest += stats.memRequest
-				totals[node].memLimit += stats.memLimit
-				totals[node].cpuUsage += stats.cpuUsage
-				totals[node].cpuRequest += stats.cpuRequest
-				totals[node].cpuLimit += stats.cpuLimit
-			} else {
-				memRow = append(memRow, "-")
-				cpuRow = append(cpuRow, "-")
-			}
-		}
-		memTableData = append(memTableData, memRow)
-		cpuTableData = append(cpuTableData, cpuRow)
+// groupNode returns the node of a node view column, nil when columns are group values
+func groupNode(cluster *kram.Cluster, name string, by kram.GroupBy) *kram.Node {
+	if !by.IsZero() {
+		return nil
 	}
+	return cluster.Node(name)
+}
 
-	memTotalRow := []string{"Total"}
-	cpuTotalRow := []string{"Total"}
-	for _, node := range nodes {
-		t := totals[node]
-		memTotalRow = append(memTotalRow, fmt.Sprintf("%.1f/%.1f/%.1f MiB",
-			toMiB(t.memUsage), toMiB(t.memRequest), toMiB(t.memLimit),
-		))
-		cpuTotalRow = append(cpuTotalRow, fmt.Sprintf("%dm/%dm/%dm",
-			t.cpuUsage, t.cpuRequest, t.cpuLimit,
-		))
+// groupHeader names the first column of the namespaces table: fallback, or the grouping
+func groupHeader(by kram.GroupBy, fallback string) string {
+	if by.IsZero() {
+		return fallback
 	}
-	memTableData = append(memTableData, memTotalRow)
-	cpuTableData = append(cpuTableData, cpuTotalRow)
+	return by.String()
+}
 
-	showMem := !onlyCPU
-	showCPU := !onlyRAM
+// ============================================================
+// METRICS — ordre des lignes (kram --sort-by cpu-usage --top 10)
+// ============================================================
 
-	if outputFormat == "html" {
-		var sections []htmlSection
-		if showMem {
-			sections = append(sections, htmlSection{Title: fmt.Sprintf("Memory Usage / Request / Limit — %s", namespace.Name), Data: memTableData})
-		}
-		if showCPU {
-			sections = append(sections, htmlSection{Title: fmt.Sprintf("CPU Usage / Request / Limit — %s", namespace.Name), Data: cpuTableData})
-		}
+// othersName labels the row summing the entries past --top
+func othersName(n int) string {
+	return fmt.Sprintf("Others (%d)", n)
+}
 
-		xLabels := make([]string, len(nodes))
-		memUsageVals := make([]float64, len(nodes))
-		memReqVals := make([]float64, len(nodes))
-		memLimVals := make([]float64, len(nodes))
-		cpuUsageVals := make([]float64, len(nodes))
-		cpuReqVals := make([]float64, len(nodes))
-		cpuLimVals := make([]float64, len(nodes))
+func groupName(g *kram.Group) string { return g.Name }
 
-		for i, node := range nodes {
-			t := totals[node]
-			xLabels[i] = shortNodeName(node)
-			memUsageVals[i] = toMiB(t.memUsage)
-			memReqVals[i] = toMiB(t.memRequest)
-			memLimVals[i] = toMiB(t.memLimit)
-			cpuUsageVals[i] = float64(t.cpuUsage)
-			cpuReqVals[i] = float64(t.cpuRequest)
-			cpuLimVals[i] = float64(t.cpuLimit)
-		}
+func podName(p *kram.Pod) string { return p.Name }
 
-		memBarChart := newBarChart([]barChartSeries{
-			{name: "Usage", values: memUsageVals},
-			{name: "Request", values: memReqVals},
-			{name: "Limit", values: memLimVals},
-		}, xLabels, fmt.Sprintf("Memory across nodes — %s", namespace.Name), "MiB")
-
-		cpuBarChart := newBarChart([]barChartSeries{
-			{name: "Usage", values: cpuUsageVals},
-			{name: "Request", values: cpuReqVals},
-			{name: "Limit", values: cpuLimVals},
-		}, xLabels, fmt.Sprintf("CPU across nodes — %s", namespace.Name), "millicores")
-
-		chartHead, chartBody := barBodySnippet(memBarChart, cpuBarChart)
-		renderHTML(sections, htmlOutputPath(fmt.Sprintf("kram-%s-nodes.html", namespace.Name)), chartHead, chartBody)
-	} else {
-		if showMem {
-			pterm.Printf("Memory Usage / Request / Limit — %s\n", namespace.Name)
-			pterm.DefaultTable.WithHeaderRowSeparator("─").WithBoxed().WithHasHeader().WithAlternateRowStyle(alternateStyle).WithData(memTableData).Render()
-		}
-		if showCPU {
-			if showMem {
-				pterm.Printf("\n")
-			}
-			pterm.Printf("CPU Usage / Request / Limit — %s\n", namespace.Name)
-			pterm.DefaultTable.WithHeaderRowSeparator("─").WithBoxed().WithHasHeader().WithAlternateRowStyle(alternateStyle).WithData(cpuTableData).Render()
-		}
-	}
-}
+func namespaceName(ns *kram.Namespace) string { return ns.Name }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PaulPowershell/Kram/pkg/kram"
+)
+
+// tableNames returns the first column of the table rows, header, Total and Others rows left out,
+// with the rows of one entry (a pod and its containers) counted once
+func tableNames(data [][]string) []string {
+	var names []string
+	for _, row := range data[1:] {
+		name := row[0]
+		if name == "Total" || strings.HasPrefix(name, "Others (") {
+			continue
+		}
+		if len(names) == 0 || names[len(names)-1] != name {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func TestReportDocumentOrder(t *testing.T) {
+	pod := func(namespace, name string, cpuUsage, cpuRequest int64) *kram.Pod {
+		return &kram.Pod{Name: name, Namespace: namespace, Phase: "Running", HasMetrics: true, Containers: []*kram.Container{
+			{Name: "app", Kind: kram.ContainerKindApp, Quantities: kram.Quantities{Usage: kram.Resources{CPU: cpuUsage}, Request: kram.Resources{CPU: cpuRequest}}},
+			{Name: "proxy", Kind: kram.ContainerKindApp, Quantities: kram.Quantities{Usage: kram.Resources{CPU: 1}, Request: kram.Resources{CPU: 10}}},
+		}}
+	}
+	cluster := &kram.Cluster{Namespaces: []*kram.Namespace{
+		{Name: "api", Pods: []*kram.Pod{pod("api", "api-1", 300, 1000), pod("api", "api-2", 20, 1000)}},
+		{Name: "batch", Pods: []*kram.Pod{pod("batch", "report", 50, 0)}},
+		{Name: "db", Pods: []*kram.Pod{pod("db", "db-0", 400, 400)}},
+		{Name: "web", Pods: []*kram.Pod{pod("web", "web-a", 10, 100), pod("web", "web-b", 90, 100), pod("web", "web-c", 200, 500)}},
+	}}
+
+	orders := []struct {
+		name  string
+		order kram.Order
+	}{
+		{name: "name"},
+		{name: "reverse", order: kram.Order{Reverse: true}},
+		{name: "cpu usage", order: kram.Order{Key: kram.SortByCPUUsage}},
+		{name: "cpu efficiency reversed", order: kram.Order{Key: kram.SortByCPUEfficiency, Reverse: true}},
+		{name: "top", order: kram.Order{Key: kram.SortByCPURequest, Top: 2}},
+	}
+
+	for _, tt := range orders {
+		t.Run("namespaces by "+tt.name, func(t *testing.T) {
+			r := namespacesReport(cluster, kram.GroupBy{}, false, tt.order)
+			var got []string
+			for _, ns := range r.document().Namespaces {
+				got = append(got, ns.Name)
+			}
+			if want := tableNames(r.Sections[0].Data); !reflect.DeepEqual(got, want) {
+				t.Errorf("document namespaces = %q, table = %q", got, want)
+			}
+		})
+		t.Run("pods by "+tt.name, func(t *testing.T) {
+			r := namespaceReport(cluster, "web", false, tt.order)
+			var got []string
+			for _, p := range r.document().Pods {
+				got = append(got, p.Name)
+			}
+			if want := tableNames(r.Sections[0].Data); !reflect.DeepEqual(got, want) {
+				t.Errorf("document pods = %q, table = %q", got, want)
+			}
+		})
+	}
+}
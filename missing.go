@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/PaulPowershell/Kram/pkg/kram"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================
+// MISSING (kram missing [namespace])
+// ============================================================
+
+func newMissingCmd(cfg *Config) *cobra.Command {
+	missingCmd := &cobra.Command{
+		Use:   "missing [namespace]",
+		Short: "List the containers missing a CPU or memory request or limit",
+		Long: "Missing lists every container of the pod specs, reported by metrics-server or not, that does not set its CPU or memory request or limit, " +
+			"by namespace and workload, with the QoS class of its pod. Tables render an unset value as " + Unset + ", unlike an explicit 0.",
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.ResolveOwners = true
+			cluster, errorsList := collectForCommand(cfg, args, false, nil)
+
+			renderReport(cfg.OutputFormat, missingReport(cluster, cfg.Namespace), errorsList)
+
+			if !renderers[cfg.OutputFormat].embedsErrors {
+				printErrors(errorsList)
+			}
+		},
+	}
+
+	addCollectionFlags(missingCmd, cfg, "List every namespace")
+
+	return missingCmd
+}
+
+func missingReport(cluster *kram.Cluster, namespace string) *report {
+	missing := cluster.MissingResources()
+
+	// Per namespace: containers of the spec, containers missing something, then the count per request and limit
+	type counts struct {
+		containers, missing                              int
+		cpuRequest, cpuLimit, memoryRequest, memoryLimit int
+	}
+	byNamespace := make(map[string]*counts)
+	var total counts
+	for _, ns := range cluster.Namespaces {
+		c := &counts{}
+		for _, pod := range ns.Pods {
+			for _, container := range pod.Containers {
+				if container.Kind != kram.ContainerKindEphemeral {
+					c.containers++
+				}
+			}
+		}
+		byNamespace[ns.Name] = c
+		total.containers += c.containers
+	}
+	count := func(c *counts, m kram.MissingResources) {
+		c.missing++
+		c.cpuRequest += missingCount(m.Set.CPURequest)
+		c.cpuLimit += missingCount(m.Set.CPULimit)
+		c.memoryRequest += missingCount(m.Set.MemoryRequest)
+		c.memoryLimit += missingCount(m.Set.MemoryLimit)
+	}
+
+	detailTableData := [][]string{{"Namespace", "Workload", "Pod", "Container", "Kind", "QoS", "CPU Request", "CPU Limit", "Mem Request", "Mem Limit"}}
+	doc := newJSONDocument("missing", namespace)
+	for _, m := range missing {
+		count(byNamespace[m.Namespace], m)
+		count(&total, m)
+		detailTableData = append(detailTableData, []string{
+			m.Namespace, m.Workload.String(), m.Pod, m.Container, string(m.Kind), m.QOSClass,
+			setCell(m.Set.CPURequest), setCell(m.Set.CPULimit), setCell(m.Set.MemoryRequest), setCell(m.Set.MemoryLimit),
+		})
+		doc.Missing = append(doc.Missing, jsonMissing{
+			Namespace:    m.Namespace,
+			WorkloadKind: m.Workload.Kind,
+			Workload:     m.Workload.Name,
+			Pod:          m.Pod,
+			Container:    m.Container,
+			Kind:         string(m.Kind),
+			QOSClass:     m.QOSClass,
+			Unset:        m.Set.Missing(),
+		})
+	}
+
+	row := func(name string, c *counts) []string {
+		return []string{name, pterm.Sprint(c.containers), pterm.Sprint(c.missing),
+			pterm.Sprint(c.cpuRequest), pterm.Sprint(c.cpuLimit), pterm.Sprint(c.memoryRequest), pterm.Sprint(c.memoryLimit)}
+	}
+	summaryTableData := [][]string{{"Namespace", "Containers", "Missing", "No CPU Request", "No CPU Limit", "No Mem Request", "No Mem Limit"}}
+	for _, ns := range cluster.Namespaces {
+		if c := byNamespace[ns.Name]; c.missing > 0 {
+			summaryTableData = append(summaryTableData, row(ns.Name, c))
+		}
+	}
+	summaryTableData = append(summaryTableData, row("Total", &total))
+
+	sections := []reportSection{{Title: "Containers missing requests or limits", Data: summaryTableData}}
+	if len(missing) > 0 {
+		sections = append(sections, reportSection{Title: fmt.Sprintf("Containers (%d)", len(missing)), Data: detailTableData})
+	}
+
+	name := "kram-missing"
+	if namespace != "" {
+		name = fmt.Sprintf("kram-missing-%s", namespace)
+	}
+	return &report{Name: name, Sections: sections, Document: doc}
+}
+
+// missingCount counts 1 for an unset request or limit
+func missingCount(set bool) int {
+	if set {
+		return 0
+	}
+	return 1
+}
+
+// setCell marks a request or limit of the detail table as set or Unset
+func setCell(set bool) string {
+	return orUnset(set, "set")
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/PaulPowershell/Kram/pkg/kram"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/pterm/pterm"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/homedir"
+)
+
+// ============================================================
+// MULTI-CLUSTER — collecte (kram --contexts a,b / --kubeconfig-glob)
+// ============================================================
+
+// clusterTarget is one cluster of a multi-cluster report
+type clusterTarget struct {
+	name       string
+	kubeconfig string
+	context    string
+}
+
+// clusterTargets lists the --contexts of the kubeconfig, then the files matching --kubeconfig-glob
+func clusterTargets(cfg *Config) ([]clusterTarget, error) {
+	kubeconfig := ""
+	if cfg.KubeFlags.KubeConfig != nil {
+		kubeconfig = *cfg.KubeFlags.KubeConfig
+	}
+
+	var targets []clusterTarget
+	for _, name := range cfg.Contexts {
+		targets = append(targets, clusterTarget{name: name, kubeconfig: kubeconfig, context: name})
+	}
+
+	if cfg.KubeconfigGlob != "" {
+		pattern := cfg.KubeconfigGlob
+		if strings.HasPrefix(pattern, "~/") {
+			pattern = filepath.Join(homedir.HomeDir(), pattern[2:])
+		}
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, ErrNoKubeconfigMatch
+		}
+		for _, file := range files {
+			name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+			targets = append(targets, clusterTarget{name: name, kubeconfig: file})
+		}
+	}
+
+	return targets, nil
+}
+
+// restConfig resolves the target with the client-go loading rules, like restConfig does for a single cluster
+func (t clusterTarget) restConfig() (*rest.Config, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = t.kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: t.context}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+}
+
+// collectClusters collects every target concurrently; errors are prefixed with the cluster name
+func collectClusters(cfg *Config) ([]*kram.Cluster, []error) {
+	targets, err := clusterTargets(cfg)
+	if err != nil {
+		pterm.Error.Println(err)
+		os.Exit(1)
+	}
+
+	bar, _ := pterm.DefaultProgressbar.
+		WithTotal(len(targets)).
+		WithTitle("Running").
+		WithRemoveWhenDone().
+		Start()
+
+	var clusters []*kram.Cluster
+	var errorsList []error
+
+	// Thread-safe synchronization for parallel processing
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(len(targets))
+
+	for _, target := range targets {
+		go func(t clusterTarget) {
+			defer wg.Done()
+			defer bar.Increment()
+
+			cluster, errs := collectTarget(cfg, t)
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, err := range errs {
+				errorsList = append(errorsList, fmt.Errorf("%s: %w", t.name, err))
+			}
+			if cluster != nil {
+				clusters = append(clusters, cluster)
+			}
+		}(target)
+	}
+
+	wg.Wait()
+
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	return clusters, errorsList
+}
+
+// collectTarget collects every namespace of one target with the collector settings of a single cluster
+func collectTarget(cfg *Config, t clusterTarget) (*kram.Cluster, []error) {
+	config, err := t.restConfig()
+	if err != nil {
+		return nil, []error{err}
+	}
+	clientset, metricsClientset, err := clientsForConfig(config)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	c := newCollector(cfg, clientset, metricsClientset)
+	namespaces, err := c.NamespaceNames(context.TODO(), "")
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	cluster, errs := c.Collect(context.TODO(), namespaces)
+	cluster.Name = t.name
+	return cluster, errs
+}
+
+// ============================================================
+// METRICS — vue multi-cluster (kram --contexts a,b -o html)
+// ============================================================
+
+func clustersReport(clusters []*kram.Cluster, by kram.GroupBy, efficiency bool, order kram.Order) *report {
+	clusterTableData := make([][]string, 0, len(clusters)+2)
+	clusterTableData = append(clusterTableData, append(append([]string{"Cluster", "Namespaces"}, podCountsHeader()...), quantitiesHeader(false)...))
+
+	sections := []reportSection{{Title: "Clusters Resource Metrics"}}
+	doc := newJSONDocument("clusters", "")
+	doc.GroupBy = by.String()
+	var totalNamespaces int
+	var totalPods kram.PodCounts
+	var total kram.Quantities
+	var xLabels []string
+	var values []kram.Quantities
+
+	for _, cluster := range clusters {
+		summary := summarizeNamespaces(cluster, by, efficiency, order)
+
+		clusterTableData = append(clusterTableData, append(append([]string{cluster.Name, pterm.Sprint(summary.namespaces)}, formatPodCounts(summary.pods)...), formatQuantities(summary.total)...))
+		sections = append(sections, reportSection{Title: fmt.Sprintf("Namespaces — %s", cluster.Name), Data: summary.table})
+		doc.Clusters = append(doc.Clusters, jsonCluster{
+			Name:          cluster.Name,
+			Pods:          summary.pods.Total(),
+			jsonPodCounts: newJSONPodCounts(summary.pods),
+			jsonResources: newJSONResources(summary.total),
+			Namespaces:    summary.records,
+		})
+		xLabels = append(xLabels, cluster.Name)
+		values = append(values, summary.total)
+
+		totalNamespaces += summary.namespaces
+		totalPods.Add(summary.pods)
+		total.Add(summary.total)
+	}
+
+	clusterTableData = append(clusterTableData, append(append([]string{"Total", pterm.Sprint(totalNamespaces)}, formatPodCounts(totalPods)...), formatQuantities(total)...))
+	sections[0].Data = clusterTableData
+	doc.Total = newJSONResources(total)
+
+	cpuBarChart, memBarChart := quantitiesBarCharts(xLabels, values,
+		"CPU — Usage / Request / Limit — Clusters",
+		"Memory — Usage / Request / Limit — Clusters")
+
+	return &report{
+		Name:     "kram-clusters",
+		Sections: sections,
+		Charts:   []*charts.Bar{cpuBarChart, memBarChart},
+		Document: doc,
+	}
+}
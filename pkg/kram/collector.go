@@ -0,0 +1,425 @@
+package kram
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// ============================================================
+// COLLECTOR — Kubernetes API → model
+// ============================================================
+
+// Collector builds a Cluster snapshot from the Kubernetes and metrics.k8s.io APIs
+type Collector struct {
+	client  kubernetes.Interface
+	metrics metricsv.Interface
+
+	// IncludeNodes also collects Node objects and node metrics (capacity, allocatable, usage)
+	IncludeNodes bool
+	// ResolveOwners also lists ReplicaSets and Jobs to resolve pods up to their top-level controller
+	// (Deployment, CronJob); without it the Deployment is guessed from the pod-template-hash label
+	ResolveOwners bool
+	// IncludeNamespaceMetadata also lists namespaces to read their labels and annotations (one extra call)
+	IncludeNamespaceMetadata bool
+	// LabelSelector restricts pods and pod metrics (e.g. "app=payments")
+	LabelSelector string
+	// FieldSelector restricts pods (e.g. "spec.nodeName=node-3"); pod metrics follow the listed pods
+	FieldSelector string
+	// NamespaceSelector restricts the namespaces listed by NamespaceNames (e.g. "team=payments")
+	NamespaceSelector string
+	// Progress, when set, is called once per collected namespace, then once per sampling tick
+	Progress func()
+	// WrapCall, when set, wraps every API call (e.g. to silence client-go logs)
+	WrapCall func(call func() error) error
+}
+
+// NewCollector creates a Collector from any implementation of the clientset interfaces
+func NewCollector(client kubernetes.Interface, metrics metricsv.Interface) *Collector {
+	return &Collector{client: client, metrics: metrics}
+}
+
+// NamespaceNames returns the given namespace, or every namespace of the cluster matching NamespaceSelector when empty
+func (c *Collector) NamespaceNames(ctx context.Context, namespace string) ([]string, error) {
+	if namespace != "" {
+		return []string{namespace}, nil
+	}
+
+	var namespaces *corev1.NamespaceList
+	err := c.call(func() error {
+		var e error
+		namespaces, e = c.client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: c.NamespaceSelector})
+		return e
+	})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(namespaces.Items))
+	for _, ns := range namespaces.Items {
+		names = append(names, ns.Name)
+	}
+	return names, nil
+}
+
+// Collect fetches pods and pod metrics of the given namespaces in parallel.
+// Errors do not abort the collection: they are returned alongside the partial snapshot.
+func (c *Collector) Collect(ctx context.Context, namespaces []string) (*Cluster, []error) {
+	cluster := &Cluster{}
+	var errorsList []error
+
+	// Thread-safe synchronization for parallel processing
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(len(namespaces))
+
+	for _, name := range namespaces {
+		go func(name string) {
+			defer wg.Done()
+			if c.Progress != nil {
+				defer c.Progress()
+			}
+
+			ns, err := c.collectNamespace(ctx, name, &errorsList, &mu)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errorsList = append(errorsList, err)
+				return
+			}
+			cluster.Namespaces = append(cluster.Namespaces, ns)
+		}(name)
+	}
+
+	wg.Wait()
+
+	if c.IncludeNodes {
+		nodes, err := c.collectNodes(ctx, &errorsList, &mu)
+		if err != nil {
+			errorsList = append(errorsList, err)
+		}
+		cluster.Nodes = nodes
+	}
+
+	if c.IncludeNamespaceMetadata {
+		if err := c.collectNamespaceMetadata(ctx, cluster); err != nil {
+			errorsList = append(errorsList, err)
+		}
+	}
+
+	sortCluster(cluster)
+	cluster.CollectedAt = time.Now()
+	return cluster, errorsList
+}
+
+func (c *Collector) collectNamespace(ctx context.Context, name string, errorsList *[]error, mu *sync.Mutex) (*Namespace, error) {
+	var pods *corev1.PodList
+	err := c.call(func() error {
+		var e error
+		pods, e = c.client.CoreV1().Pods(name).List(ctx, c.podListOptions())
+		return e
+	})
+	if err != nil {
+		return nil, err
+	}
+	if pods.Items, err = c.filterPodFields(pods.Items); err != nil {
+		return nil, err
+	}
+
+	ns := &Namespace{Name: name}
+	if len(pods.Items) == 0 {
+		return ns, nil
+	}
+
+	// Fetch all metrics for namespace at once (1 API call instead of N)
+	metricsMap := c.getNamespacePodMetricsMap(ctx, name, errorsList, mu)
+
+	var owners map[ownerKey]Workload
+	if c.ResolveOwners {
+		owners = c.getNamespaceOwnersMap(ctx, name, pods.Items, errorsList, mu)
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		p := &Pod{Name: pod.Name, Namespace: pod.Namespace, NodeName: pod.Spec.NodeName, Labels: pod.Labels, Annotations: pod.Annotations, Workload: podWorkload(pod, owners), QOSClass: podQOSClass(pod), Phase: podPhase(pod)}
+		ns.Pods = append(ns.Pods, p)
+		p.Overhead = Resources{
+			CPU:    pod.Spec.Overhead.Cpu().MilliValue(),
+			Memory: pod.Spec.Overhead.Memory().Value(),
+		}
+
+		usage := make(map[string]Resources)
+		if podMetrics, ok := metricsMap[pod.Name]; ok {
+			p.HasMetrics = true
+			for _, containerMetrics := range podMetrics.Containers {
+				usage[containerMetrics.Name] = Resources{
+					CPU:    containerMetrics.Usage.Cpu().MilliValue(),
+					Memory: containerMetrics.Usage.Memory().Value(),
+				}
+			}
+		}
+
+		for _, spec := range getContainerSpecs(pod) {
+			container := &Container{Name: spec.name, Kind: spec.kind}
+			container.Usage = usage[spec.name]
+			container.Request = Resources{
+				CPU:    spec.resources.Requests.Cpu().MilliValue(),
+				Memory: spec.resources.Requests.Memory().Value(),
+			}
+			container.Limit = Resources{
+				CPU:    spec.resources.Limits.Cpu().MilliValue(),
+				Memory: spec.resources.Limits.Memory().Value(),
+			}
+			container.Set = specified(spec.resources)
+			p.Containers = append(p.Containers, container)
+		}
+	}
+
+	return ns, nil
+}
+
+// collectNodes fetches Node objects and their metrics; missing node metrics are reported in errorsList
+func (c *Collector) collectNodes(ctx context.Context, errorsList *[]error, mu *sync.Mutex) ([]*Node, error) {
+	var nodeList *corev1.NodeList
+	err := c.call(func() error {
+		var e error
+		nodeList, e = c.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		return e
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var nodeMetricsList *metricsv1beta1.NodeMetricsList
+	err = c.call(func() error {
+		var e error
+		nodeMetricsList, e = c.metrics.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{})
+		return e
+	})
+	nodeMetricsMap := make(map[string]*metricsv1beta1.NodeMetrics)
+	if err != nil {
+		mu.Lock()
+		*errorsList = append(*errorsList, err)
+		mu.Unlock()
+	} else {
+		for i := range nodeMetricsList.Items {
+			nodeMetricsMap[nodeMetricsList.Items[i].Name] = &nodeMetricsList.Items[i]
+		}
+	}
+
+	nodes := make([]*Node, 0, len(nodeList.Items))
+	for _, item := range nodeList.Items {
+		node := &Node{
+			Name:   item.Name,
+			Labels: item.Labels,
+			Capacity: Resources{
+				CPU:    item.Status.Capacity.Cpu().MilliValue(),
+				Memory: item.Status.Capacity.Memory().Value(),
+			},
+			Allocatable: Resources{
+				CPU:    item.Status.Allocatable.Cpu().MilliValue(),
+				Memory: item.Status.Allocatable.Memory().Value(),
+			},
+		}
+		if nodeMetrics, ok := nodeMetricsMap[item.Name]; ok {
+			node.HasMetrics = true
+			node.Usage = Resources{
+				CPU:    nodeMetrics.Usage.Cpu().MilliValue(),
+				Memory: nodeMetrics.Usage.Memory().Value(),
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// collectNamespaceMetadata copies the labels and annotations of the collected namespaces
+func (c *Collector) collectNamespaceMetadata(ctx context.Context, cluster *Cluster) error {
+	var namespaces *corev1.NamespaceList
+	err := c.call(func() error {
+		var e error
+		namespaces, e = c.client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		return e
+	})
+	if err != nil {
+		return err
+	}
+	for _, item := range namespaces.Items {
+		if ns := cluster.Namespace(item.Name); ns != nil {
+			ns.Labels, ns.Annotations = item.Labels, item.Annotations
+		}
+	}
+	return nil
+}
+
+// podListOptions applies the selectors to pod lists
+func (c *Collector) podListOptions() metav1.ListOptions {
+	return metav1.ListOptions{LabelSelector: c.LabelSelector, FieldSelector: c.FieldSelector}
+}
+
+// podMetricsListOptions applies the label selector to pod metrics lists. metrics-server only knows
+// the metadata fields of PodMetrics, so field selectors are left to the pod list metrics are matched with.
+func (c *Collector) podMetricsListOptions() metav1.ListOptions {
+	return metav1.ListOptions{LabelSelector: c.LabelSelector}
+}
+
+// filterPodFields matches the pods against FieldSelector again: the API server already did,
+// but the fake clientsets replaying a snapshot ignore field selectors
+func (c *Collector) filterPodFields(pods []corev1.Pod) ([]corev1.Pod, error) {
+	if c.FieldSelector == "" {
+		return pods, nil
+	}
+	selector, err := fields.ParseSelector(c.FieldSelector)
+	if err != nil {
+		return nil, err
+	}
+	filtered := pods[:0]
+	for i := range pods {
+		if selector.Matches(podFields(&pods[i])) {
+			filtered = append(filtered, pods[i])
+		}
+	}
+	return filtered, nil
+}
+
+// podFields returns the fields the API server accepts in pod field selectors
+func podFields(pod *corev1.Pod) fields.Set {
+	return fields.Set{
+		"metadata.name":            pod.Name,
+		"metadata.namespace":       pod.Namespace,
+		"spec.nodeName":            pod.Spec.NodeName,
+		"spec.restartPolicy":       string(pod.Spec.RestartPolicy),
+		"spec.schedulerName":       pod.Spec.SchedulerName,
+		"spec.serviceAccountName":  pod.Spec.ServiceAccountName,
+		"spec.hostNetwork":         strconv.FormatBool(pod.Spec.HostNetwork),
+		"status.phase":             string(pod.Status.Phase),
+		"status.podIP":             pod.Status.PodIP,
+		"status.nominatedNodeName": pod.Status.NominatedNodeName,
+	}
+}
+
+// call runs fn through WrapCall when set
+func (c *Collector) call(fn func() error) error {
+	if c.WrapCall != nil {
+		return c.WrapCall(fn)
+	}
+	return fn()
+}
+
+// getNamespacePodMetricsMap fetches all pod metrics for a namespace with a single API call
+// and returns them as a map for O(1) lookup instead of O(n) per-pod .Get() calls
+func (c *Collector) getNamespacePodMetricsMap(ctx context.Context, namespace string, errorsList *[]error, mu *sync.Mutex) map[string]*metricsv1beta1.PodMetrics {
+	result := make(map[string]*metricsv1beta1.PodMetrics)
+
+	var podMetricsList *metricsv1beta1.PodMetricsList
+	err := c.call(func() error {
+		var e error
+		podMetricsList, e = c.metrics.MetricsV1beta1().PodMetricses(namespace).List(ctx, c.podMetricsListOptions())
+		return e
+	})
+
+	if err != nil {
+		mu.Lock()
+		*errorsList = append(*errorsList, err)
+		mu.Unlock()
+		return result
+	}
+
+	for i := range podMetricsList.Items {
+		pod := &podMetricsList.Items[i]
+		result[pod.Name] = pod
+	}
+
+	return result
+}
+
+// containerSpec is a container of any kind of the pod spec
+type containerSpec struct {
+	name      string
+	kind      ContainerKind
+	resources corev1.ResourceRequirements
+}
+
+// getContainerSpecs lists the init containers (sidecars among them), the containers and the ephemeral
+// containers of the pod, in the order kube-scheduler accounts for them
+func getContainerSpecs(pod *corev1.Pod) []containerSpec {
+	specs := make([]containerSpec, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers)+len(pod.Spec.EphemeralContainers))
+	for _, c := range pod.Spec.InitContainers {
+		kind := ContainerKindInit
+		if c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			kind = ContainerKindSidecar
+		}
+		specs = append(specs, containerSpec{name: c.Name, kind: kind, resources: c.Resources})
+	}
+	for _, c := range pod.Spec.Containers {
+		specs = append(specs, containerSpec{name: c.Name, kind: ContainerKindApp, resources: c.Resources})
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		specs = append(specs, containerSpec{name: c.Name, kind: ContainerKindEphemeral, resources: c.Resources})
+	}
+	return specs
+}
+
+// specified records the requests and limits present in the spec, an explicit 0 included
+func specified(res corev1.ResourceRequirements) Specified {
+	_, cpuRequest := res.Requests[corev1.ResourceCPU]
+	_, cpuLimit := res.Limits[corev1.ResourceCPU]
+	_, memoryRequest := res.Requests[corev1.ResourceMemory]
+	_, memoryLimit := res.Limits[corev1.ResourceMemory]
+	return Specified{CPURequest: cpuRequest, CPULimit: cpuLimit, MemoryRequest: memoryRequest, MemoryLimit: memoryLimit}
+}
+
+// podPhase returns the phase reported by the kubelet. Pods replayed from a snapshot taken
+// without status are Pending until bound to a node, Running afterwards.
+func podPhase(pod *corev1.Pod) string {
+	switch {
+	case pod.Status.Phase != "":
+		return string(pod.Status.Phase)
+	case pod.Spec.NodeName == "":
+		return string(corev1.PodPending)
+	default:
+		return string(corev1.PodRunning)
+	}
+}
+
+// podQOSClass returns the QoS class set by the API server. Pods replayed from a snapshot
+// taken without it are classified from their containers with the kubelet rules.
+func podQOSClass(pod *corev1.Pod) string {
+	if pod.Status.QOSClass != "" {
+		return string(pod.Status.QOSClass)
+	}
+
+	guaranteed, bestEffort := true, true
+	for _, c := range getContainerSpecs(pod) {
+		if c.kind == ContainerKindEphemeral {
+			continue
+		}
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			request, hasRequest := c.resources.Requests[name]
+			limit, hasLimit := c.resources.Limits[name]
+			if hasRequest || hasLimit {
+				bestEffort = false
+			}
+			// The API server defaults a missing request to the limit
+			if !hasLimit || (hasRequest && request.Cmp(limit) != 0) {
+				guaranteed = false
+			}
+		}
+	}
+	switch {
+	case bestEffort:
+		return string(corev1.PodQOSBestEffort)
+	case guaranteed:
+		return string(corev1.PodQOSGuaranteed)
+	default:
+		return string(corev1.PodQOSBurstable)
+	}
+}
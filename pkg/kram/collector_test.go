@@ -0,0 +1,133 @@
+package kram
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFilterPodFields(t *testing.T) {
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "web"}, Spec: corev1.PodSpec{NodeName: "node-1"}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "api-2", Namespace: "web"}, Spec: corev1.PodSpec{NodeName: "node-2"}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "report-x", Namespace: "web"}, Spec: corev1.PodSpec{NodeName: "node-1"}, Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "api-3", Namespace: "web"}, Status: corev1.PodStatus{Phase: corev1.PodPending}},
+	}
+
+	tests := []struct {
+		name     string
+		selector string
+		want     []string
+		wantErr  bool
+	}{
+		{name: "no selector", want: []string{"api-1", "api-2", "report-x", "api-3"}},
+		{name: "node", selector: "spec.nodeName=node-1", want: []string{"api-1", "report-x"}},
+		{name: "not a phase", selector: "status.phase!=Succeeded", want: []string{"api-1", "api-2", "api-3"}},
+		{name: "unscheduled", selector: "spec.nodeName=", want: []string{"api-3"}},
+		{name: "both", selector: "spec.nodeName=node-1,status.phase=Running", want: []string{"api-1"}},
+		{name: "invalid", selector: "spec.nodeName", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Collector{FieldSelector: tt.selector}
+			filtered, err := c.filterPodFields(append([]corev1.Pod(nil), pods...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("filterPodFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, p := range filtered {
+				got = append(got, p.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterPodFields() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodQOSClass(t *testing.T) {
+	resources := func(requests, limits corev1.ResourceList) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{Requests: requests, Limits: limits}
+	}
+	tests := []struct {
+		name string
+		pod  corev1.Pod
+		want string
+	}{
+		{
+			name: "status wins",
+			pod: corev1.Pod{
+				Spec:   corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+				Status: corev1.PodStatus{QOSClass: corev1.PodQOSGuaranteed},
+			},
+			want: "Guaranteed",
+		},
+		{
+			name: "nothing set",
+			pod:  corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}, {Name: "proxy"}}}},
+			want: "BestEffort",
+		},
+		{
+			name: "limits only, defaulted as requests",
+			pod: corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Resources: resources(nil, resourceList("500m", "256Mi"))},
+			}}},
+			want: "Guaranteed",
+		},
+		{
+			name: "requests equal to limits",
+			pod: corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Resources: resources(resourceList("500m", "256Mi"), resourceList("500m", "256Mi"))},
+				{Name: "proxy", Resources: resources(resourceList("100m", "64Mi"), resourceList("0.1", "64Mi"))},
+			}}},
+			want: "Guaranteed",
+		},
+		{
+			name: "one container without limit",
+			pod: corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Resources: resources(resourceList("500m", "256Mi"), resourceList("500m", "256Mi"))},
+				{Name: "proxy"},
+			}}},
+			want: "Burstable",
+		},
+		{
+			name: "init containers count, ephemeral ones do not",
+			pod: corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "migrate", Resources: resources(resourceList("100m", "64Mi"), nil)}},
+				Containers:     []corev1.Container{{Name: "app", Resources: resources(nil, resourceList("500m", "256Mi"))}},
+				EphemeralContainers: []corev1.EphemeralContainer{
+					{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger"}},
+				},
+			}},
+			want: "Burstable",
+		},
+		{
+			name: "ephemeral containers without resources keep the class",
+			pod: corev1.Pod{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app", Resources: resources(nil, resourceList("500m", "256Mi"))}},
+				EphemeralContainers: []corev1.EphemeralContainer{
+					{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger"}},
+				},
+			}},
+			want: "Guaranteed",
+		},
+		{
+			name: "request below the limit",
+			pod: corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Resources: resources(resourceList("250m", "256Mi"), resourceList("500m", "256Mi"))},
+			}}},
+			want: "Burstable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := podQOSClass(&tt.pod); got != tt.want {
+				t.Errorf("podQOSClass() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package kram
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ============================================================
+// COST — estimated cost from a pricing file
+// ============================================================
+
+// HoursPerMonth is the average number of hours in a month (365 × 24 / 12)
+const HoursPerMonth = 730
+
+// Rates are prices per hour: one vCPU (1000 millicores) and one GiB of memory
+type Rates struct {
+	CPUHour       float64 `json:"cpuHour"`
+	MemoryGiBHour float64 `json:"memoryGiBHour"`
+}
+
+// PriceRule prices the nodes carrying every label of NodeSelector
+type PriceRule struct {
+	// Name is shown in the node report, e.g. "spot" or "D8s_v5"
+	Name         string            `json:"name"`
+	NodeSelector map[string]string `json:"nodeSelector"`
+	Rates
+}
+
+// Pricing is read from a YAML file:
+//
+//	currency: EUR
+//	cpuHour: 0.035
+//	memoryGiBHour: 0.005
+//	nodes:
+//	  - name: spot
+//	    nodeSelector: {kubernetes.azure.com/scalesetpriority: spot}
+//	    cpuHour: 0.008
+//	    memoryGiBHour: 0.001
+//
+// The first rule matching the labels of a node applies, the default rates otherwise.
+type Pricing struct {
+	Currency string `json:"currency"`
+	Rates
+	Nodes []PriceRule `json:"nodes,omitempty"`
+}
+
+// ReadPricing loads a pricing file
+func ReadPricing(path string) (*Pricing, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &Pricing{}
+	if err := yaml.UnmarshalStrict(data, p); err != nil {
+		return nil, fmt.Errorf("cannot decode pricing %s: %w", path, err)
+	}
+	if p.CPUHour < 0 || p.MemoryGiBHour < 0 {
+		return nil, fmt.Errorf("negative default rates in pricing %s", path)
+	}
+	for _, rule := range p.Nodes {
+		if len(rule.NodeSelector) == 0 || rule.CPUHour < 0 || rule.MemoryGiBHour < 0 {
+			return nil, fmt.Errorf("pricing rule %q in %s needs a nodeSelector and non-negative rates", rule.Name, path)
+		}
+	}
+	return p, nil
+}
+
+// RatesFor returns the rates of the node and the name of the rule applied ("default" when none matches).
+// Pods whose node is unknown (not collected, not scheduled) use the default rates.
+func (p *Pricing) RatesFor(node *Node) (Rates, string) {
+	if node != nil {
+		for _, rule := range p.Nodes {
+			if matchLabels(node.Labels, rule.NodeSelector) {
+				return rule.Rates, rule.Name
+			}
+		}
+	}
+	return p.Rates, "default"
+}
+
+func matchLabels(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Cost is an hourly amount split between CPU and memory
+type Cost struct {
+	CPU    float64
+	Memory float64
+}
+
+// Total returns the hourly cost of CPU and memory
+func (c Cost) Total() float64 {
+	return c.CPU + c.Memory
+}
+
+// Add accumulates other into c
+func (c *Cost) Add(other Cost) {
+	c.CPU += other.CPU
+	c.Memory += other.Memory
+}
+
+// Cost prices resources for one hour
+func (r Rates) Cost(res Resources) Cost {
+	return Cost{
+		CPU:    float64(res.CPU) / 1000 * r.CPUHour,
+		Memory: float64(res.Memory) / (1 << 30) * r.MemoryGiBHour,
+	}
+}
+
+// CostBasis selects the quantity a container is charged for
+type CostBasis string
+
+const (
+	CostByRequest CostBasis = "request"
+	CostByUsage   CostBasis = "usage"
+	// CostByMax charges the request, or the usage when the container goes above it
+	CostByMax CostBasis = "max"
+)
+
+// Charged returns the resources of the container charged under basis b
+func (b CostBasis) Charged(c *Container) Resources {
+	switch b {
+	case CostByRequest:
+		return c.Request
+	case CostByUsage:
+		return c.Usage
+	default:
+		return maxResources(c.Request, c.Usage)
+	}
+}
+
+// podCharged returns the charged resources of the pod: its effective request or usage (see Pod.Total),
+// or with CostByMax the running containers charged one by one, never below the effective request.
+// Completed pods hold nothing on their node and are charged zero under every basis.
+func (b CostBasis) podCharged(p *Pod) Resources {
+	if p.Completed() {
+		return Resources{}
+	}
+	total := p.Total()
+	switch b {
+	case CostByRequest:
+		return total.Request
+	case CostByUsage:
+		return total.Usage
+	}
+	var res Resources
+	for _, c := range p.Containers {
+		if c.Kind != ContainerKindInit {
+			res.Add(b.Charged(c))
+		}
+	}
+	return maxResources(res, total.Request)
+}
+
+// PodCost is the hourly cost of one pod at the rates of its node
+type PodCost struct {
+	Pod     *Pod
+	Charged Resources
+	Cost    Cost
+}
+
+// NodeCost is the hourly cost of the allocatable resources of a node,
+// split between what the pods are charged for and the idle remainder
+type NodeCost struct {
+	Node *Node
+	// Rule is the name of the pricing rule applied to the node
+	Rule string
+	// Pods counts the pods not completed on the node
+	Pods    int
+	Charged Resources
+	// Unallocated is the allocatable not charged to any pod
+	Unallocated Resources
+	// Cost prices the allocatable resources, Allocated the charged ones within it and Idle the rest
+	Cost      Cost
+	Allocated Cost
+	Idle      Cost
+}
+
+// PodCosts prices every pod of the cluster, in cluster order. Completed pods are kept at zero cost.
+func PodCosts(cluster *Cluster, pricing *Pricing, basis CostBasis) []PodCost {
+	var costs []PodCost
+	for _, pod := range cluster.Pods() {
+		rates, _ := pricing.RatesFor(cluster.Node(pod.NodeName))
+		charged := basis.podCharged(pod)
+		costs = append(costs, PodCost{Pod: pod, Charged: charged, Cost: rates.Cost(charged)})
+	}
+	return costs
+}
+
+// NodeCosts prices the allocatable resources of every collected node. The idle cost is the
+// allocatable not charged to any pod; pods charged above it leave no idle cost on that resource.
+func NodeCosts(cluster *Cluster, pricing *Pricing, basis CostBasis) []NodeCost {
+	charged := make(map[string]Resources)
+	pods := make(map[string]int)
+	for _, pod := range cluster.Pods() {
+		res := charged[pod.NodeName]
+		res.Add(basis.podCharged(pod))
+		charged[pod.NodeName] = res
+		if !pod.Completed() {
+			pods[pod.NodeName]++
+		}
+	}
+
+	costs := make([]NodeCost, 0, len(cluster.Nodes))
+	for _, node := range cluster.Nodes {
+		rates, rule := pricing.RatesFor(node)
+		res := charged[node.Name]
+		allocated := Resources{CPU: min(res.CPU, node.Allocatable.CPU), Memory: min(res.Memory, node.Allocatable.Memory)}
+		idle := Resources{CPU: node.Allocatable.CPU - allocated.CPU, Memory: node.Allocatable.Memory - allocated.Memory}
+		costs = append(costs, NodeCost{
+			Node:        node,
+			Rule:        rule,
+			Pods:        pods[node.Name],
+			Charged:     res,
+			Unallocated: idle,
+			Cost:        rates.Cost(node.Allocatable),
+			Allocated:   rates.Cost(allocated),
+			Idle:        rates.Cost(idle),
+		})
+	}
+	return costs
+}
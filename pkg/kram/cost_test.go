@@ -0,0 +1,168 @@
+package kram
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPodCharged(t *testing.T) {
+	const mi = 1 << 20
+	// api bursts above its CPU request, proxy above its memory request
+	pod := &Pod{Name: "api-1", Containers: []*Container{
+		{Name: "api", Quantities: Quantities{Usage: Resources{CPU: 300, Memory: 64 * mi}, Request: Resources{CPU: 100, Memory: 128 * mi}}},
+		{Name: "proxy", Quantities: Quantities{Usage: Resources{CPU: 10, Memory: 32 * mi}, Request: Resources{CPU: 50, Memory: 16 * mi}}},
+	}}
+
+	// The init container requests more CPU than the app container, which bursts above its own request
+	migrate := &Pod{Name: "api-2", Containers: []*Container{
+		{Name: "migrate", Kind: ContainerKindInit, Quantities: Quantities{Request: Resources{CPU: 500, Memory: 16 * mi}}},
+		{Name: "api", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 300, Memory: 64 * mi}, Request: Resources{CPU: 100, Memory: 128 * mi}}},
+	}}
+	report := &Pod{Name: "report-x", Phase: "Failed", Containers: []*Container{
+		{Name: "report", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 20, Memory: 64 * mi}, Request: Resources{CPU: 1000, Memory: 512 * mi}}},
+	}}
+
+	tests := []struct {
+		name  string
+		pod   *Pod
+		basis CostBasis
+		want  Resources
+	}{
+		{name: "request", pod: pod, basis: CostByRequest, want: Resources{CPU: 150, Memory: 144 * mi}},
+		{name: "usage", pod: pod, basis: CostByUsage, want: Resources{CPU: 310, Memory: 96 * mi}},
+		{name: "max of each container", pod: pod, basis: CostByMax, want: Resources{CPU: 350, Memory: 160 * mi}},
+		{name: "request follows the init container rule", pod: migrate, basis: CostByRequest, want: Resources{CPU: 500, Memory: 128 * mi}},
+		{name: "max never below the effective request", pod: migrate, basis: CostByMax, want: Resources{CPU: 500, Memory: 128 * mi}},
+		{name: "completed pod on request", pod: report, basis: CostByRequest, want: Resources{}},
+		{name: "completed pod on usage", pod: report, basis: CostByUsage, want: Resources{}},
+		{name: "completed pod on max", pod: report, basis: CostByMax, want: Resources{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.basis.podCharged(tt.pod); got != tt.want {
+				t.Errorf("podCharged() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRatesFor(t *testing.T) {
+	pricing := &Pricing{
+		Rates: Rates{CPUHour: 0.04},
+		Nodes: []PriceRule{
+			{Name: "spot", NodeSelector: map[string]string{"priority": "spot"}, Rates: Rates{CPUHour: 0.01}},
+			{Name: "gpu", NodeSelector: map[string]string{"pool": "gpu"}, Rates: Rates{CPUHour: 0.5}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		node     *Node
+		wantRule string
+		wantCPU  float64
+	}{
+		{name: "unknown node", wantRule: "default", wantCPU: 0.04},
+		{name: "no rule matches", node: &Node{Name: "node-1", Labels: map[string]string{"pool": "system"}}, wantRule: "default", wantCPU: 0.04},
+		{name: "first matching rule", node: &Node{Name: "node-2", Labels: map[string]string{"pool": "gpu", "priority": "spot"}}, wantRule: "spot", wantCPU: 0.01},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rates, rule := pricing.RatesFor(tt.node)
+			if rule != tt.wantRule || rates.CPUHour != tt.wantCPU {
+				t.Errorf("RatesFor() = %v, %s, want %v, %s", rates.CPUHour, rule, tt.wantCPU, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestNodeCosts(t *testing.T) {
+	pricing := &Pricing{
+		Rates: Rates{CPUHour: 1},
+		Nodes: []PriceRule{{Name: "spot", NodeSelector: map[string]string{"pool": "spot"}, Rates: Rates{CPUHour: 0.5}}},
+	}
+	request := func(name, node string, cpu int64) *Pod {
+		return &Pod{Name: name, NodeName: node, Containers: []*Container{{Name: name, Quantities: Quantities{Request: Resources{CPU: cpu}}}}}
+	}
+	cluster := &Cluster{
+		Namespaces: []*Namespace{{Name: "web", Pods: []*Pod{
+			request("api-1", "node-1", 1000),
+			// Completed: neither counted nor charged
+			{Name: "report-x", NodeName: "node-1", Phase: "Succeeded", Containers: []*Container{{Name: "report", Quantities: Quantities{Request: Resources{CPU: 2000}}}}},
+			request("api-2", "node-2", 1500),
+			request("api-3", "node-2", 1500),
+			// Pending: charged, but on no node
+			request("api-4", "", 500),
+		}}},
+		Nodes: []*Node{
+			{Name: "node-1", Labels: map[string]string{"pool": "spot"}, Allocatable: Resources{CPU: 4000}},
+			{Name: "node-2", Labels: map[string]string{"pool": "standard"}, Allocatable: Resources{CPU: 2000}},
+		},
+	}
+	want := []struct {
+		rule                  string
+		pods                  int
+		charged, unallocated  Resources
+		cost, allocated, idle float64
+	}{
+		{rule: "spot", pods: 1, charged: Resources{CPU: 1000}, unallocated: Resources{CPU: 3000}, cost: 2, allocated: 0.5, idle: 1.5},
+		// Charged above the allocatable: no idle cost
+		{rule: "default", pods: 2, charged: Resources{CPU: 3000}, cost: 2, allocated: 2},
+	}
+
+	got := NodeCosts(cluster, pricing, CostByRequest)
+	if len(got) != len(want) {
+		t.Fatalf("NodeCosts() returned %d nodes, want %d", len(got), len(want))
+	}
+	for i, nc := range got {
+		w := want[i]
+		if nc.Rule != w.rule || nc.Pods != w.pods || nc.Charged != w.charged || nc.Unallocated != w.unallocated {
+			t.Errorf("%s: rule %s, %d pods, charged %+v, unallocated %+v, want %s, %d, %+v, %+v",
+				nc.Node.Name, nc.Rule, nc.Pods, nc.Charged, nc.Unallocated, w.rule, w.pods, w.charged, w.unallocated)
+		}
+		if !closeTo(nc.Cost.Total(), w.cost) || !closeTo(nc.Allocated.Total(), w.allocated) || !closeTo(nc.Idle.Total(), w.idle) {
+			t.Errorf("%s: cost %v, allocated %v, idle %v, want %v, %v, %v", nc.Node.Name, nc.Cost.Total(), nc.Allocated.Total(), nc.Idle.Total(), w.cost, w.allocated, w.idle)
+		}
+	}
+}
+
+func TestRatesCost(t *testing.T) {
+	rates := Rates{CPUHour: 0.04, MemoryGiBHour: 0.005}
+	got := rates.Cost(Resources{CPU: 500, Memory: 4 << 30})
+	if !closeTo(got.CPU, 0.02) || !closeTo(got.Memory, 0.02) {
+		t.Errorf("Cost() = %+v, want {CPU:0.02 Memory:0.02}", got)
+	}
+}
+
+func TestReadPricing(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "default rates and a rule", content: "currency: EUR\ncpuHour: 0.035\nmemoryGiBHour: 0.005\nnodes:\n  - name: spot\n    nodeSelector: {pool: spot}\n    cpuHour: 0.008\n"},
+		{name: "free rule", content: "cpuHour: 0.035\nnodes:\n  - name: reserved\n    nodeSelector: {pool: reserved}\n"},
+		{name: "negative default rate", content: "cpuHour: -1\n", wantErr: true},
+		{name: "rule without selector", content: "nodes:\n  - name: spot\n    cpuHour: 0.008\n", wantErr: true},
+		{name: "unknown field", content: "cpuPerHour: 0.035\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "pricing.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ReadPricing(path); (err != nil) != tt.wantErr {
+				t.Errorf("ReadPricing() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,174 @@
+package kram
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ============================================================
+// GROUP — aggregation by label, annotation or node label
+// ============================================================
+
+// Unlabelled is the group of the pods without a value for the key
+const Unlabelled = "unlabelled"
+
+// Sources of a GroupBy
+const (
+	// GroupByLabel reads the pod label, then the label of its namespace
+	GroupByLabel = "label"
+	// GroupByAnnotation reads the pod annotation, then the annotation of its namespace
+	GroupByAnnotation = "annotation"
+	// GroupByNodeLabel reads the label of the node the pod runs on
+	GroupByNodeLabel = "node-label"
+)
+
+// GroupBy selects the value pods are aggregated under, e.g. label:team or node-label:topology.kubernetes.io/zone.
+// The zero value groups nothing.
+type GroupBy struct {
+	Source string
+	Key    string
+}
+
+// ParseGroupBy reads source:key; an empty string returns the zero GroupBy
+func ParseGroupBy(s string) (GroupBy, error) {
+	if s == "" {
+		return GroupBy{}, nil
+	}
+	source, key, ok := strings.Cut(s, ":")
+	if !ok || key == "" {
+		return GroupBy{}, fmt.Errorf("invalid group %q, expected label:<key>, annotation:<key> or node-label:<key>", s)
+	}
+	switch source {
+	case GroupByLabel, GroupByAnnotation, GroupByNodeLabel:
+		return GroupBy{Source: source, Key: key}, nil
+	default:
+		return GroupBy{}, fmt.Errorf("invalid group source %q, expected label, annotation or node-label", source)
+	}
+}
+
+// IsZero reports whether no grouping is selected
+func (g GroupBy) IsZero() bool {
+	return g.Source == ""
+}
+
+// String returns the source:key form accepted by ParseGroupBy
+func (g GroupBy) String() string {
+	if g.IsZero() {
+		return ""
+	}
+	return g.Source + ":" + g.Key
+}
+
+// Group is a set of pods sharing one value of a GroupBy
+type Group struct {
+	Name string
+	Pods []*Pod
+}
+
+// Total sums the quantities of all pods of the group
+func (g *Group) Total() Quantities {
+	var total Quantities
+	for _, p := range g.Pods {
+		total.Add(p.Total())
+	}
+	return total
+}
+
+// Waste sums the waste of the pods of the group
+func (g *Group) Waste() Resources {
+	var waste Resources
+	for _, p := range g.Pods {
+		waste.Add(p.Waste())
+	}
+	return waste
+}
+
+// UsageSamples sums the readings of every pod of the group; nil without sampling
+func (g *Group) UsageSamples() []Resources {
+	var samples []Resources
+	for _, p := range g.Pods {
+		samples = AddSamples(samples, p.UsageSamples())
+	}
+	return samples
+}
+
+// GroupOf returns the function giving the group of a pod, Unlabelled when the key is not set.
+// Namespace values need the collector to run with IncludeNamespaceMetadata, node labels with IncludeNodes.
+func (c *Cluster) GroupOf(by GroupBy) func(*Pod) string {
+	namespaces := make(map[string]*Namespace, len(c.Namespaces))
+	for _, ns := range c.Namespaces {
+		namespaces[ns.Name] = ns
+	}
+
+	return func(p *Pod) string {
+		var value string
+		switch by.Source {
+		case GroupByLabel:
+			value = p.Labels[by.Key]
+			if ns := namespaces[p.Namespace]; value == "" && ns != nil {
+				value = ns.Labels[by.Key]
+			}
+		case GroupByAnnotation:
+			value = p.Annotations[by.Key]
+			if ns := namespaces[p.Namespace]; value == "" && ns != nil {
+				value = ns.Annotations[by.Key]
+			}
+		case GroupByNodeLabel:
+			if node := c.Node(p.NodeName); node != nil {
+				value = node.Labels[by.Key]
+			}
+		}
+		if value == "" {
+			return Unlabelled
+		}
+		return value
+	}
+}
+
+// Groups aggregates the pods of the cluster by value, sorted by name with Unlabelled last
+func (c *Cluster) Groups(by GroupBy) []*Group {
+	groupOf := c.GroupOf(by)
+	byName := make(map[string]*Group)
+	var groups []*Group
+	for _, p := range c.Pods() {
+		name := groupOf(p)
+		g, ok := byName[name]
+		if !ok {
+			g = &Group{Name: name}
+			byName[name] = g
+			groups = append(groups, g)
+		}
+		g.Pods = append(g.Pods, p)
+	}
+	sort.Slice(groups, func(i, j int) bool { return GroupLess(groups[i].Name, groups[j].Name) })
+	return groups
+}
+
+// AggregateBy sums the pods per value of key, sorted by value with Unlabelled and Unscheduled last
+func AggregateBy(pods []*Pod, key func(*Pod) string) []NodeQuantities {
+	byKey := make(map[string]*NodeQuantities)
+	for _, p := range pods {
+		k := key(p)
+		if _, ok := byKey[k]; !ok {
+			byKey[k] = &NodeQuantities{Name: k}
+		}
+		byKey[k].Add(p.Total())
+	}
+
+	result := make([]NodeQuantities, 0, len(byKey))
+	for _, n := range byKey {
+		result = append(result, *n)
+	}
+	sort.Slice(result, func(i, j int) bool { return GroupLess(result[i].Name, result[j].Name) })
+	return result
+}
+
+// GroupLess orders group values (or nodes) by name, Unlabelled and Unscheduled last
+func GroupLess(a, b string) bool {
+	lastA, lastB := a == Unlabelled || a == Unscheduled, b == Unlabelled || b == Unscheduled
+	if lastA != lastB {
+		return lastB
+	}
+	return a < b
+}
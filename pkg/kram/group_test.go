@@ -0,0 +1,120 @@
+package kram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGroupBy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    GroupBy
+		wantErr bool
+	}{
+		{in: "", want: GroupBy{}},
+		{in: "label:team", want: GroupBy{Source: GroupByLabel, Key: "team"}},
+		{in: "node-label:topology.kubernetes.io/zone", want: GroupBy{Source: GroupByNodeLabel, Key: "topology.kubernetes.io/zone"}},
+		{in: "team", wantErr: true},
+		{in: "label:", wantErr: true},
+		{in: "owner:team", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseGroupBy(tt.in)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseGroupBy(%q) = %+v, %v, want %+v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		cluster *Cluster
+		by      GroupBy
+		want    map[string][]string
+		order   []string
+	}{
+		{
+			name: "pod label over namespace label",
+			cluster: &Cluster{Namespaces: []*Namespace{
+				{Name: "shop", Labels: map[string]string{"team": "sales"}, Pods: []*Pod{
+					{Name: "cart", Namespace: "shop", Labels: map[string]string{"team": "checkout"}},
+					{Name: "catalog", Namespace: "shop"},
+				}},
+				{Name: "tools", Pods: []*Pod{{Name: "debug", Namespace: "tools"}}},
+			}},
+			by:    GroupBy{Source: GroupByLabel, Key: "team"},
+			want:  map[string][]string{"checkout": {"cart"}, "sales": {"catalog"}, Unlabelled: {"debug"}},
+			order: []string{"checkout", "sales", Unlabelled},
+		},
+		{
+			name: "annotation falls back to the namespace",
+			cluster: &Cluster{Namespaces: []*Namespace{
+				{Name: "billing", Annotations: map[string]string{"cost-center": "cc-42"}, Pods: []*Pod{
+					{Name: "invoice", Namespace: "billing"},
+					{Name: "ledger", Namespace: "billing", Annotations: map[string]string{"cost-center": "cc-7"}},
+				}},
+			}},
+			by:    GroupBy{Source: GroupByAnnotation, Key: "cost-center"},
+			want:  map[string][]string{"cc-42": {"invoice"}, "cc-7": {"ledger"}},
+			order: []string{"cc-42", "cc-7"},
+		},
+		{
+			name: "node label, unscheduled pods unlabelled",
+			cluster: &Cluster{
+				Namespaces: []*Namespace{{Name: "web", Pods: []*Pod{
+					{Name: "api-1", Namespace: "web", NodeName: "node-b"},
+					{Name: "api-2", Namespace: "web", NodeName: "node-a"},
+					{Name: "api-3", Namespace: "web"},
+					{Name: "api-4", Namespace: "web", NodeName: "node-c"},
+				}}},
+				Nodes: []*Node{
+					{Name: "node-a", Labels: map[string]string{"zone": "eu-1"}},
+					{Name: "node-b", Labels: map[string]string{"zone": "eu-2"}},
+					{Name: "node-c"},
+				},
+			},
+			by:    GroupBy{Source: GroupByNodeLabel, Key: "zone"},
+			want:  map[string][]string{"eu-1": {"api-2"}, "eu-2": {"api-1"}, Unlabelled: {"api-3", "api-4"}},
+			order: []string{"eu-1", "eu-2", Unlabelled},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[string][]string)
+			var order []string
+			for _, g := range tt.cluster.Groups(tt.by) {
+				order = append(order, g.Name)
+				for _, p := range g.Pods {
+					got[g.Name] = append(got[g.Name], p.Name)
+				}
+			}
+			if !reflect.DeepEqual(order, tt.order) || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Groups() = %v in order %q, want %v in order %q", got, order, tt.want, tt.order)
+			}
+		})
+	}
+}
+
+func TestAggregateBy(t *testing.T) {
+	pods := []*Pod{
+		{Name: "cart", Labels: map[string]string{"team": "checkout"}, Containers: []*Container{{Name: "cart", Quantities: Quantities{Request: Resources{CPU: 100}}}}},
+		{Name: "debug", Containers: []*Container{{Name: "debug", Quantities: Quantities{Request: Resources{CPU: 10}}}}},
+		{Name: "pay", Labels: map[string]string{"team": "checkout"}, Containers: []*Container{{Name: "pay", Quantities: Quantities{Request: Resources{CPU: 200}}}}},
+		{Name: "search", Labels: map[string]string{"team": "catalog"}, Containers: []*Container{{Name: "search", Quantities: Quantities{Request: Resources{CPU: 50}}}}},
+	}
+	team := (&Cluster{}).GroupOf(GroupBy{Source: GroupByLabel, Key: "team"})
+	want := []NodeQuantities{
+		{Name: "catalog", Quantities: Quantities{Request: Resources{CPU: 50}}},
+		{Name: "checkout", Quantities: Quantities{Request: Resources{CPU: 300}}},
+		{Name: Unlabelled, Quantities: Quantities{Request: Resources{CPU: 10}}},
+	}
+
+	if got := AggregateBy(pods, team); !reflect.DeepEqual(got, want) {
+		t.Errorf("AggregateBy() = %+v, want %+v", got, want)
+	}
+}
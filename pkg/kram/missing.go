@@ -0,0 +1,44 @@
+package kram
+
+// ============================================================
+// MISSING — containers without CPU or memory request/limit
+// ============================================================
+
+// MissingResources is a container of the pod spec not setting some of its requests and limits
+type MissingResources struct {
+	Namespace string
+	Workload  Workload
+	Pod       string
+	Container string
+	Kind      ContainerKind
+	QOSClass  string
+	// Set tells which of the CPU and memory requests and limits the container sets
+	Set Specified
+}
+
+// MissingResources lists the containers of the cluster missing a CPU or memory request or limit,
+// in namespace, workload, pod and container order. Ephemeral containers, which cannot set any, are left out.
+func (c *Cluster) MissingResources() []MissingResources {
+	var result []MissingResources
+	for _, ns := range c.Namespaces {
+		for _, w := range ns.Workloads() {
+			for _, pod := range w.Pods {
+				for _, c := range pod.Containers {
+					if c.Kind == ContainerKindEphemeral || len(c.Set.Missing()) == 0 {
+						continue
+					}
+					result = append(result, MissingResources{
+						Namespace: ns.Name,
+						Workload:  pod.Workload,
+						Pod:       pod.Name,
+						Container: c.Name,
+						Kind:      c.Kind,
+						QOSClass:  pod.QOSClass,
+						Set:       c.Set,
+					})
+				}
+			}
+		}
+	}
+	return result
+}
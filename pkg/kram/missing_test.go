@@ -0,0 +1,68 @@
+package kram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMissingResources(t *testing.T) {
+	all := Specified{CPURequest: true, CPULimit: true, MemoryRequest: true, MemoryLimit: true}
+	requests := Specified{CPURequest: true, MemoryRequest: true}
+	api := Workload{Kind: "Deployment", Name: "api"}
+	cluster := &Cluster{Namespaces: []*Namespace{
+		{Name: "web", Pods: []*Pod{
+			{Name: "api-1", Namespace: "web", Workload: api, QOSClass: "Burstable", Containers: []*Container{
+				{Name: "migrate", Kind: ContainerKindInit, Quantities: Quantities{Set: all}},
+				{Name: "api", Kind: ContainerKindApp, Quantities: Quantities{Set: requests}},
+				{Name: "debugger", Kind: ContainerKindEphemeral},
+			}},
+			{Name: "api-2", Namespace: "web", Workload: api, QOSClass: "Burstable", Containers: []*Container{
+				{Name: "migrate", Kind: ContainerKindInit, Quantities: Quantities{Set: all}},
+				{Name: "api", Kind: ContainerKindApp, Quantities: Quantities{Set: requests}},
+			}},
+			{Name: "cache-0", Namespace: "web", Workload: Workload{Kind: "StatefulSet", Name: "cache"}, QOSClass: "Guaranteed", Containers: []*Container{
+				{Name: "redis", Kind: ContainerKindApp, Quantities: Quantities{Set: all}},
+			}},
+			// Pods without metrics are listed as well
+			{Name: "debug", Namespace: "web", Workload: Workload{Kind: "Pod", Name: "debug"}, QOSClass: "BestEffort", Containers: []*Container{
+				{Name: "shell", Kind: ContainerKindApp},
+			}},
+		}},
+		{Name: "batch", Pods: []*Pod{
+			{Name: "report-x", Namespace: "batch", Workload: Workload{Kind: "Job", Name: "report"}, QOSClass: "Burstable", Containers: []*Container{
+				{Name: "mesh", Kind: ContainerKindSidecar, Quantities: Quantities{Set: Specified{MemoryRequest: true, MemoryLimit: true}}},
+				{Name: "report", Kind: ContainerKindApp, Quantities: Quantities{Set: all}},
+			}},
+		}},
+	}}
+	want := []MissingResources{
+		{Namespace: "web", Workload: api, Pod: "api-1", Container: "api", Kind: ContainerKindApp, QOSClass: "Burstable", Set: requests},
+		{Namespace: "web", Workload: api, Pod: "api-2", Container: "api", Kind: ContainerKindApp, QOSClass: "Burstable", Set: requests},
+		{Namespace: "web", Workload: Workload{Kind: "Pod", Name: "debug"}, Pod: "debug", Container: "shell", Kind: ContainerKindApp, QOSClass: "BestEffort"},
+		{Namespace: "batch", Workload: Workload{Kind: "Job", Name: "report"}, Pod: "report-x", Container: "mesh", Kind: ContainerKindSidecar, QOSClass: "Burstable", Set: Specified{MemoryRequest: true, MemoryLimit: true}},
+	}
+
+	if got := cluster.MissingResources(); !reflect.DeepEqual(got, want) {
+		t.Errorf("MissingResources() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestSpecifiedMissing(t *testing.T) {
+	tests := []struct {
+		name string
+		set  Specified
+		want []string
+	}{
+		{name: "nothing set", want: []string{CPURequest, CPULimit, MemoryRequest, MemoryLimit}},
+		{name: "requests set", set: Specified{CPURequest: true, MemoryRequest: true}, want: []string{CPULimit, MemoryLimit}},
+		{name: "everything set", set: Specified{CPURequest: true, CPULimit: true, MemoryRequest: true, MemoryLimit: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.set.Missing(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Missing() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,369 @@
+// Package kram collects Kubernetes resource usage, requests and limits into a typed model.
+package kram
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// ============================================================
+// MODEL — cluster → namespace → node → pod → container
+// ============================================================
+
+// Resources holds raw quantities: CPU in millicores, memory in bytes
+type Resources struct {
+	CPU    int64
+	Memory int64
+}
+
+// Quantities groups the usage, request and limit of a container or an aggregate
+type Quantities struct {
+	Usage   Resources
+	Request Resources
+	Limit   Resources
+	// Set tells an unset request or limit (counted as 0) from an explicit 0
+	Set Specified
+}
+
+// Specified records which requests and limits a container spec sets;
+// an aggregate has a flag when at least one of its containers sets it
+type Specified struct {
+	CPURequest    bool
+	CPULimit      bool
+	MemoryRequest bool
+	MemoryLimit   bool
+}
+
+// Cluster is a point-in-time snapshot produced by the collector
+type Cluster struct {
+	// Name identifies the cluster in multi-cluster reports; empty otherwise
+	Name       string
+	Namespaces []*Namespace
+	// Nodes is only filled when the collector runs with IncludeNodes
+	Nodes []*Node
+	// Samples is the number of usage readings per container, 0 for a single reading
+	Samples int
+	// CollectedAt is the end of the collection
+	CollectedAt time.Time
+}
+
+// Node holds the capacity of a cluster node and its actual usage
+type Node struct {
+	Name        string
+	Labels      map[string]string
+	Capacity    Resources
+	Allocatable Resources
+	// Usage is the whole node usage reported by metrics-server, system daemons included
+	Usage      Resources
+	HasMetrics bool
+}
+
+type Namespace struct {
+	Name string
+	// Labels and Annotations are only filled when the collector runs with IncludeNamespaceMetadata
+	Labels      map[string]string
+	Annotations map[string]string
+	Pods        []*Pod
+}
+
+// Unscheduled is the node of the pending pods not bound to a node yet; node names
+// are lowercase, so it cannot collide with one
+const Unscheduled = "Unscheduled"
+
+type Pod struct {
+	Name      string
+	Namespace string
+	// NodeName is empty until the pod is scheduled, see ScheduledOn
+	NodeName    string
+	Labels      map[string]string
+	Annotations map[string]string
+	// Workload is the controller owning the pod, the pod itself when it has none
+	Workload Workload
+	// HasMetrics is false when metrics-server returned nothing for the pod (pending, completed, just started)
+	HasMetrics bool
+	// QOSClass is Guaranteed, Burstable or BestEffort
+	QOSClass string
+	// Phase is Pending, Running, Succeeded, Failed or Unknown
+	Phase string
+	// Containers follow the spec order: init containers and sidecars, containers, then ephemeral containers
+	Containers []*Container
+	// Overhead is the pod overhead of its RuntimeClass, accounted on top of the containers
+	Overhead Resources
+}
+
+// Workload identifies a pod controller (e.g. Deployment/payments)
+type Workload struct {
+	Kind string
+	Name string
+}
+
+// String returns the workload as kind/name, the form kubectl accepts
+func (w Workload) String() string {
+	return strings.ToLower(w.Kind) + "/" + w.Name
+}
+
+// ContainerKind tells how a container of the pod spec runs
+type ContainerKind string
+
+const (
+	ContainerKindApp ContainerKind = "container"
+	// ContainerKindInit runs to completion before the containers start
+	ContainerKindInit ContainerKind = "init"
+	// ContainerKindSidecar is a restartable init container, running alongside the containers
+	ContainerKindSidecar   ContainerKind = "sidecar"
+	ContainerKindEphemeral ContainerKind = "ephemeral"
+)
+
+// Container is a container of the pod spec; its usage stays 0 when metrics-server did not report it
+type Container struct {
+	Name string
+	Kind ContainerKind
+	Quantities
+	// Samples holds one usage reading per tick of a sampling window, nil for a single reading
+	Samples []Resources
+}
+
+// NodeQuantities is the aggregate of a set of pods scheduled on one node, or sharing one group value
+type NodeQuantities struct {
+	Name string
+	Quantities
+}
+
+// Add accumulates other into r
+func (r *Resources) Add(other Resources) {
+	r.CPU += other.CPU
+	r.Memory += other.Memory
+}
+
+// Add accumulates other into q
+func (q *Quantities) Add(other Quantities) {
+	q.Usage.Add(other.Usage)
+	q.Request.Add(other.Request)
+	q.Limit.Add(other.Limit)
+	q.Set.Merge(other.Set)
+}
+
+// Merge marks the requests and limits other sets
+func (s *Specified) Merge(other Specified) {
+	s.CPURequest = s.CPURequest || other.CPURequest
+	s.CPULimit = s.CPULimit || other.CPULimit
+	s.MemoryRequest = s.MemoryRequest || other.MemoryRequest
+	s.MemoryLimit = s.MemoryLimit || other.MemoryLimit
+}
+
+// Missing names the requests and limits not set: cpuRequest, cpuLimit, memoryRequest, memoryLimit
+func (s Specified) Missing() []string {
+	var missing []string
+	for _, f := range []struct {
+		set  bool
+		name string
+	}{{s.CPURequest, CPURequest}, {s.CPULimit, CPULimit}, {s.MemoryRequest, MemoryRequest}, {s.MemoryLimit, MemoryLimit}} {
+		if !f.set {
+			missing = append(missing, f.name)
+		}
+	}
+	return missing
+}
+
+// PodCounts counts pods by phase: Completed holds Succeeded and Failed pods, Running the Unknown ones too
+type PodCounts struct {
+	Running   int
+	Pending   int
+	Completed int
+}
+
+// CountPods counts the pods by phase
+func CountPods(pods []*Pod) PodCounts {
+	var counts PodCounts
+	for _, p := range pods {
+		switch {
+		case p.Completed():
+			counts.Completed++
+		case p.Phase == "Pending":
+			counts.Pending++
+		default:
+			counts.Running++
+		}
+	}
+	return counts
+}
+
+// Add accumulates other into c
+func (c *PodCounts) Add(other PodCounts) {
+	c.Running += other.Running
+	c.Pending += other.Pending
+	c.Completed += other.Completed
+}
+
+// Total is the number of pods counted
+func (c PodCounts) Total() int {
+	return c.Running + c.Pending + c.Completed
+}
+
+// Completed reports whether the pod has terminated (Succeeded or Failed): it no longer holds resources
+func (p *Pod) Completed() bool {
+	return p.Phase == "Succeeded" || p.Phase == "Failed"
+}
+
+// ScheduledOn returns the node the pod is bound to, Unscheduled when it is pending without one
+func (p *Pod) ScheduledOn() string {
+	if p.NodeName == "" {
+		return Unscheduled
+	}
+	return p.NodeName
+}
+
+// Waste is the part of the request the container does not use, never negative
+func (c *Container) Waste() Resources {
+	return Resources{
+		CPU:    max(c.Request.CPU-c.Usage.CPU, 0),
+		Memory: max(c.Request.Memory-c.Usage.Memory, 0),
+	}
+}
+
+// Waste sums the waste of the running containers: a container above its request does not offset another one.
+// Init containers are left out, their request is only held while they run, and so are pods without metrics.
+func (p *Pod) Waste() Resources {
+	var waste Resources
+	if !p.HasMetrics {
+		return waste
+	}
+	for _, c := range p.Containers {
+		if c.Kind != ContainerKindInit {
+			waste.Add(c.Waste())
+		}
+	}
+	return waste
+}
+
+// Waste sums the waste of the pods of the namespace
+func (ns *Namespace) Waste() Resources {
+	var waste Resources
+	for _, p := range ns.Pods {
+		waste.Add(p.Waste())
+	}
+	return waste
+}
+
+// Total sums the usage of all containers of the pod. Requests and limits are the effective ones
+// kube-scheduler accounts: the containers plus the sidecars, or an init container plus the sidecars
+// declared before it when that is larger, resource by resource, plus the overhead (added to the limits that are set).
+// A completed pod holds no request nor limit.
+func (p *Pod) Total() Quantities {
+	var total Quantities
+	// sidecars accumulates the sidecars started so far, init the largest init container step
+	var sidecars, init Quantities
+	for _, c := range p.Containers {
+		total.Usage.Add(c.Usage)
+		switch c.Kind {
+		case ContainerKindInit:
+			init.Request = maxResources(init.Request, sumResources(sidecars.Request, c.Request))
+			init.Limit = maxResources(init.Limit, sumResources(sidecars.Limit, c.Limit))
+		case ContainerKindSidecar:
+			sidecars.Request.Add(c.Request)
+			sidecars.Limit.Add(c.Limit)
+			init.Request = maxResources(init.Request, sidecars.Request)
+			init.Limit = maxResources(init.Limit, sidecars.Limit)
+			total.Request.Add(c.Request)
+			total.Limit.Add(c.Limit)
+		case ContainerKindEphemeral:
+			// Ephemeral containers cannot set resources
+			continue
+		default:
+			total.Request.Add(c.Request)
+			total.Limit.Add(c.Limit)
+		}
+		total.Set.Merge(c.Set)
+	}
+	if p.Completed() {
+		total.Request, total.Limit = Resources{}, Resources{}
+		return total
+	}
+	total.Request = maxResources(total.Request, init.Request)
+	total.Limit = maxResources(total.Limit, init.Limit)
+
+	total.Request.Add(p.Overhead)
+	if total.Limit.CPU > 0 {
+		total.Limit.CPU += p.Overhead.CPU
+	}
+	if total.Limit.Memory > 0 {
+		total.Limit.Memory += p.Overhead.Memory
+	}
+	return total
+}
+
+func sumResources(a, b Resources) Resources {
+	a.Add(b)
+	return a
+}
+
+// Total sums the quantities of all pods of the namespace
+func (ns *Namespace) Total() Quantities {
+	var total Quantities
+	for _, p := range ns.Pods {
+		total.Add(p.Total())
+	}
+	return total
+}
+
+// ByNode aggregates the namespace pods per node, sorted by node name with Unscheduled last
+func (ns *Namespace) ByNode() []NodeQuantities {
+	return aggregateByNode(ns.Pods)
+}
+
+// Namespace returns the namespace with the given name, or nil
+func (c *Cluster) Namespace(name string) *Namespace {
+	for _, ns := range c.Namespaces {
+		if ns.Name == name {
+			return ns
+		}
+	}
+	return nil
+}
+
+// Pods returns the pods of every namespace
+func (c *Cluster) Pods() []*Pod {
+	var pods []*Pod
+	for _, ns := range c.Namespaces {
+		pods = append(pods, ns.Pods...)
+	}
+	return pods
+}
+
+// Total sums the quantities of all namespaces
+func (c *Cluster) Total() Quantities {
+	var total Quantities
+	for _, ns := range c.Namespaces {
+		total.Add(ns.Total())
+	}
+	return total
+}
+
+// Node returns the node with the given name, or nil when nodes were not collected
+func (c *Cluster) Node(name string) *Node {
+	for _, n := range c.Nodes {
+		if n.Name == name {
+			return n
+		}
+	}
+	return nil
+}
+
+// ByNode aggregates every pod of the cluster per node, sorted by node name with Unscheduled last
+func (c *Cluster) ByNode() []NodeQuantities {
+	return aggregateByNode(c.Pods())
+}
+
+func aggregateByNode(pods []*Pod) []NodeQuantities {
+	return AggregateBy(pods, (*Pod).ScheduledOn)
+}
+
+// sortCluster orders namespaces and pods by name so every view is deterministic
+func sortCluster(c *Cluster) {
+	sort.Slice(c.Namespaces, func(i, j int) bool { return c.Namespaces[i].Name < c.Namespaces[j].Name })
+	for _, ns := range c.Namespaces {
+		sort.Slice(ns.Pods, func(i, j int) bool { return ns.Pods[i].Name < ns.Pods[j].Name })
+	}
+	sort.Slice(c.Nodes, func(i, j int) bool { return c.Nodes[i].Name < c.Nodes[j].Name })
+}
@@ -0,0 +1,121 @@
+package kram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPodTotal(t *testing.T) {
+	const mi = 1 << 20
+	tests := []struct {
+		name string
+		pod  Pod
+		want Quantities
+	}{
+		{
+			name: "containers are summed",
+			pod: Pod{Containers: []*Container{
+				{Name: "api", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 10, Memory: 100 * mi}, Request: Resources{CPU: 100, Memory: 128 * mi}, Limit: Resources{CPU: 200, Memory: 256 * mi}}},
+				{Name: "proxy", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 5, Memory: 20 * mi}, Request: Resources{CPU: 50, Memory: 32 * mi}, Limit: Resources{CPU: 100, Memory: 64 * mi}}},
+			}},
+			want: Quantities{Usage: Resources{CPU: 15, Memory: 120 * mi}, Request: Resources{CPU: 150, Memory: 160 * mi}, Limit: Resources{CPU: 300, Memory: 320 * mi}},
+		},
+		{
+			name: "larger init container wins resource by resource",
+			pod: Pod{Containers: []*Container{
+				{Name: "migrate", Kind: ContainerKindInit, Quantities: Quantities{Request: Resources{CPU: 500, Memory: 64 * mi}, Limit: Resources{CPU: 1000, Memory: 128 * mi}}},
+				{Name: "api", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 10, Memory: 100 * mi}, Request: Resources{CPU: 100, Memory: 128 * mi}, Limit: Resources{CPU: 200, Memory: 256 * mi}}},
+			}},
+			want: Quantities{Usage: Resources{CPU: 10, Memory: 100 * mi}, Request: Resources{CPU: 500, Memory: 128 * mi}, Limit: Resources{CPU: 1000, Memory: 256 * mi}},
+		},
+		{
+			name: "init container declared after a sidecar runs alongside it",
+			pod: Pod{Containers: []*Container{
+				{Name: "mesh", Kind: ContainerKindSidecar, Quantities: Quantities{Usage: Resources{CPU: 5, Memory: 20 * mi}, Request: Resources{CPU: 50, Memory: 32 * mi}, Limit: Resources{CPU: 100, Memory: 64 * mi}}},
+				{Name: "migrate", Kind: ContainerKindInit, Quantities: Quantities{Request: Resources{CPU: 500, Memory: 64 * mi}, Limit: Resources{CPU: 1000, Memory: 128 * mi}}},
+				{Name: "api", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 10, Memory: 100 * mi}, Request: Resources{CPU: 100, Memory: 128 * mi}, Limit: Resources{CPU: 200, Memory: 256 * mi}}},
+			}},
+			want: Quantities{Usage: Resources{CPU: 15, Memory: 120 * mi}, Request: Resources{CPU: 550, Memory: 160 * mi}, Limit: Resources{CPU: 1100, Memory: 320 * mi}},
+		},
+		{
+			name: "init container declared before a sidecar runs alone",
+			pod: Pod{Containers: []*Container{
+				{Name: "migrate", Kind: ContainerKindInit, Quantities: Quantities{Request: Resources{CPU: 500, Memory: 64 * mi}, Limit: Resources{CPU: 1000, Memory: 128 * mi}}},
+				{Name: "mesh", Kind: ContainerKindSidecar, Quantities: Quantities{Usage: Resources{CPU: 5, Memory: 20 * mi}, Request: Resources{CPU: 50, Memory: 32 * mi}, Limit: Resources{CPU: 100, Memory: 64 * mi}}},
+				{Name: "api", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 10, Memory: 100 * mi}, Request: Resources{CPU: 100, Memory: 128 * mi}, Limit: Resources{CPU: 200, Memory: 256 * mi}}},
+			}},
+			want: Quantities{Usage: Resources{CPU: 15, Memory: 120 * mi}, Request: Resources{CPU: 500, Memory: 160 * mi}, Limit: Resources{CPU: 1000, Memory: 320 * mi}},
+		},
+		{
+			name: "ephemeral containers count in the usage only",
+			pod: Pod{Containers: []*Container{
+				{Name: "api", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 10, Memory: 100 * mi}, Request: Resources{CPU: 100, Memory: 128 * mi}, Limit: Resources{CPU: 200, Memory: 256 * mi}}},
+				{Name: "debugger", Kind: ContainerKindEphemeral, Quantities: Quantities{Usage: Resources{CPU: 1, Memory: 8 * mi}}},
+			}},
+			want: Quantities{Usage: Resources{CPU: 11, Memory: 108 * mi}, Request: Resources{CPU: 100, Memory: 128 * mi}, Limit: Resources{CPU: 200, Memory: 256 * mi}},
+		},
+		{
+			name: "overhead is added to requests and to the limits that are set",
+			pod: Pod{Overhead: Resources{CPU: 250, Memory: 120 * mi}, Containers: []*Container{
+				{Name: "vm", Kind: ContainerKindApp, Quantities: Quantities{
+					Usage: Resources{CPU: 10, Memory: 100 * mi}, Request: Resources{CPU: 100, Memory: 128 * mi}, Limit: Resources{Memory: 256 * mi},
+					Set: Specified{CPURequest: true, MemoryRequest: true, MemoryLimit: true},
+				}},
+			}},
+			want: Quantities{
+				Usage: Resources{CPU: 10, Memory: 100 * mi}, Request: Resources{CPU: 350, Memory: 248 * mi}, Limit: Resources{Memory: 376 * mi},
+				Set: Specified{CPURequest: true, MemoryRequest: true, MemoryLimit: true},
+			},
+		},
+		{
+			name: "completed pod holds no request nor limit",
+			pod: Pod{Phase: "Succeeded", Overhead: Resources{CPU: 250, Memory: 120 * mi}, Containers: []*Container{
+				{Name: "report", Kind: ContainerKindApp, Quantities: Quantities{Request: Resources{CPU: 1000, Memory: 512 * mi}, Limit: Resources{CPU: 2000, Memory: 512 * mi}}},
+			}},
+			want: Quantities{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pod.Total(); got != tt.want {
+				t.Errorf("Total() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountPods(t *testing.T) {
+	pods := []*Pod{
+		{Name: "api-1", Phase: "Running"},
+		{Name: "api-2", Phase: "Pending"},
+		{Name: "api-3", Phase: "Unknown"},
+		{Name: "report-1", Phase: "Succeeded"},
+		{Name: "report-2", Phase: "Failed"},
+	}
+	want := PodCounts{Running: 2, Pending: 1, Completed: 2}
+
+	got := CountPods(pods)
+	if got != want || got.Total() != len(pods) {
+		t.Errorf("CountPods() = %+v (total %d), want %+v", got, got.Total(), want)
+	}
+}
+
+func TestClusterByNode(t *testing.T) {
+	request := func(name, node string, cpu int64) *Pod {
+		return &Pod{Name: name, NodeName: node, Phase: "Running", Containers: []*Container{{Name: name, Quantities: Quantities{Request: Resources{CPU: cpu}}}}}
+	}
+	cluster := &Cluster{Namespaces: []*Namespace{
+		{Name: "batch", Pods: []*Pod{request("report", "node-b", 500), request("queued", "", 1000)}},
+		{Name: "web", Pods: []*Pod{request("api-1", "node-b", 100), request("api-2", "node-a", 100)}},
+	}}
+	want := []NodeQuantities{
+		{Name: "node-a", Quantities: Quantities{Request: Resources{CPU: 100}}},
+		{Name: "node-b", Quantities: Quantities{Request: Resources{CPU: 600}}},
+		{Name: Unscheduled, Quantities: Quantities{Request: Resources{CPU: 1000}}},
+	}
+
+	if got := cluster.ByNode(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ByNode() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package kram
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ============================================================
+// ORDER — sort key, direction and top N of the report rows
+// ============================================================
+
+// SortKey selects the value rows are ordered by
+type SortKey string
+
+const (
+	SortByName          SortKey = "name"
+	SortByCPUUsage      SortKey = "cpu-usage"
+	SortByCPURequest    SortKey = "cpu-request"
+	SortByCPULimit      SortKey = "cpu-limit"
+	SortByMemoryUsage   SortKey = "mem-usage"
+	SortByMemoryRequest SortKey = "mem-request"
+	SortByMemoryLimit   SortKey = "mem-limit"
+	// SortByCPUEfficiency and SortByMemoryEfficiency order by usage/request, SortByEfficiency by the mean of both
+	SortByCPUEfficiency    SortKey = "cpu-efficiency"
+	SortByMemoryEfficiency SortKey = "mem-efficiency"
+	SortByEfficiency       SortKey = "efficiency"
+)
+
+// SortKeys lists the accepted keys in the order of the help text
+var SortKeys = []SortKey{
+	SortByName,
+	SortByCPUUsage, SortByCPURequest, SortByCPULimit,
+	SortByMemoryUsage, SortByMemoryRequest, SortByMemoryLimit,
+	SortByCPUEfficiency, SortByMemoryEfficiency, SortByEfficiency,
+}
+
+// ParseSortKey checks s against SortKeys; an empty string is the default name order
+func ParseSortKey(s string) (SortKey, error) {
+	if s == "" {
+		return SortByName, nil
+	}
+	for _, key := range SortKeys {
+		if SortKey(s) == key {
+			return key, nil
+		}
+	}
+	names := make([]string, len(SortKeys))
+	for i, key := range SortKeys {
+		names[i] = string(key)
+	}
+	return "", fmt.Errorf("invalid sort key %q, expected one of %s", s, strings.Join(names, ", "))
+}
+
+// Order arranges report rows. The zero value keeps every row in name order.
+//
+// Names ascend, quantities descend (largest first) and efficiencies ascend (least used request
+// first, rows without request last); Reverse flips the direction and ties are ordered by name.
+type Order struct {
+	Key     SortKey
+	Reverse bool
+	// Top keeps the first Top rows, 0 keeps them all
+	Top int
+}
+
+// IsDefault reports whether rows keep their name order and are all kept
+func (o Order) IsDefault() bool {
+	return (o.Key == "" || o.Key == SortByName) && !o.Reverse && o.Top == 0
+}
+
+// value returns the number compared for q, false when it is not defined (efficiency without request)
+func (k SortKey) value(q Quantities) (float64, bool) {
+	switch k {
+	case SortByCPUUsage:
+		return float64(q.Usage.CPU), true
+	case SortByCPURequest:
+		return float64(q.Request.CPU), true
+	case SortByCPULimit:
+		return float64(q.Limit.CPU), true
+	case SortByMemoryUsage:
+		return float64(q.Usage.Memory), true
+	case SortByMemoryRequest:
+		return float64(q.Request.Memory), true
+	case SortByMemoryLimit:
+		return float64(q.Limit.Memory), true
+	case SortByCPUEfficiency:
+		return ratio(q.Usage.CPU, q.Request.CPU)
+	case SortByMemoryEfficiency:
+		return ratio(q.Usage.Memory, q.Request.Memory)
+	case SortByEfficiency:
+		cpu, cpuOK := ratio(q.Usage.CPU, q.Request.CPU)
+		mem, memOK := ratio(q.Usage.Memory, q.Request.Memory)
+		switch {
+		case cpuOK && memOK:
+			return (cpu + mem) / 2, true
+		case cpuOK:
+			return cpu, true
+		default:
+			return mem, memOK
+		}
+	}
+	return 0, false
+}
+
+func ratio(part, whole int64) (float64, bool) {
+	if whole == 0 {
+		return 0, false
+	}
+	return float64(part) / float64(whole), true
+}
+
+// ascending reports whether the key orders smallest first before Reverse
+func (k SortKey) ascending() bool {
+	switch k {
+	case SortByCPUEfficiency, SortByMemoryEfficiency, SortByEfficiency:
+		return true
+	}
+	return false
+}
+
+// Arrange orders items, which must be in name order, and splits them into the first Top rows and the rest
+func Arrange[T any](items []T, o Order, name func(T) string, total func(T) Quantities) (kept []T, rest []T) {
+	if o.IsDefault() {
+		return items, nil
+	}
+	sorted := make([]T, len(items))
+	copy(sorted, items)
+
+	if o.Key == "" || o.Key == SortByName {
+		// items are already in name order
+		if o.Reverse {
+			slices.Reverse(sorted)
+		}
+	} else {
+		sort.SliceStable(sorted, func(i, j int) bool {
+			a, b := sorted[i], sorted[j]
+			va, okA := o.Key.value(total(a))
+			vb, okB := o.Key.value(total(b))
+			// Undefined values stay last in both directions
+			if okA != okB {
+				return okA
+			}
+			if okA && va != vb {
+				return (va < vb) == (o.Key.ascending() != o.Reverse)
+			}
+			return name(a) < name(b)
+		})
+	}
+
+	if o.Top > 0 && len(sorted) > o.Top {
+		return sorted[:o.Top], sorted[o.Top:]
+	}
+	return sorted, nil
+}
@@ -0,0 +1,78 @@
+package kram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSortKey(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    SortKey
+		wantErr bool
+	}{
+		{in: "", want: SortByName},
+		{in: "mem-usage", want: SortByMemoryUsage},
+		{in: "efficiency", want: SortByEfficiency},
+		{in: "memory", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSortKey(tt.in)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseSortKey(%q) = %q, %v, want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestArrange(t *testing.T) {
+	type row struct {
+		name string
+		q    Quantities
+	}
+	// In name order, as the views pass them
+	rows := []row{
+		{name: "api", q: Quantities{Usage: Resources{CPU: 300}, Request: Resources{CPU: 1000}}},
+		{name: "batch", q: Quantities{Usage: Resources{CPU: 50}}},
+		{name: "db", q: Quantities{Usage: Resources{CPU: 300}, Request: Resources{CPU: 400}}},
+		{name: "web", q: Quantities{Usage: Resources{CPU: 100}, Request: Resources{CPU: 500}}},
+	}
+
+	tests := []struct {
+		name     string
+		order    Order
+		wantKept []string
+		wantRest []string
+	}{
+		{name: "default", wantKept: []string{"api", "batch", "db", "web"}},
+		{name: "name reversed", order: Order{Reverse: true}, wantKept: []string{"web", "db", "batch", "api"}},
+		{name: "quantity largest first, ties by name", order: Order{Key: SortByCPUUsage}, wantKept: []string{"api", "db", "web", "batch"}},
+		{name: "quantity reversed", order: Order{Key: SortByCPUUsage, Reverse: true}, wantKept: []string{"batch", "web", "api", "db"}},
+		{name: "efficiency least used first, no request last", order: Order{Key: SortByCPUEfficiency}, wantKept: []string{"web", "api", "db", "batch"}},
+		{name: "efficiency reversed, no request still last", order: Order{Key: SortByCPUEfficiency, Reverse: true}, wantKept: []string{"db", "api", "web", "batch"}},
+		{name: "top", order: Order{Key: SortByCPURequest, Top: 2}, wantKept: []string{"api", "web"}, wantRest: []string{"db", "batch"}},
+		{name: "top of the name order", order: Order{Top: 3}, wantKept: []string{"api", "batch", "db"}, wantRest: []string{"web"}},
+		{name: "top above the row count", order: Order{Key: SortByCPUUsage, Top: 10}, wantKept: []string{"api", "db", "web", "batch"}},
+	}
+
+	names := func(rows []row) []string {
+		var names []string
+		for _, r := range rows {
+			names = append(names, r.name)
+		}
+		return names
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, rest := Arrange(rows, tt.order, func(r row) string { return r.name }, func(r row) Quantities { return r.q })
+			if !reflect.DeepEqual(names(kept), tt.wantKept) || !reflect.DeepEqual(names(rest), tt.wantRest) {
+				t.Errorf("Arrange() = %q and %q, want %q and %q", names(kept), names(rest), tt.wantKept, tt.wantRest)
+			}
+		})
+	}
+	if got := names(rows); !reflect.DeepEqual(got, []string{"api", "batch", "db", "web"}) {
+		t.Errorf("Arrange() reordered its input: %q", got)
+	}
+}
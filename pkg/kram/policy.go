@@ -0,0 +1,330 @@
+package kram
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// ============================================================
+// POLICY — resource hygiene rules (kram check)
+// ============================================================
+
+// Severity of a rule; only errors fail a check
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Scope selects what a rule is evaluated on
+type Scope string
+
+const (
+	ScopeContainer Scope = "container"
+	ScopePod       Scope = "pod"
+	ScopeWorkload  Scope = "workload"
+	ScopeNamespace Scope = "namespace"
+)
+
+// Quantity names accepted by Rule.Require and Rule.Max
+const (
+	CPUUsage      = "cpuUsage"
+	CPURequest    = "cpuRequest"
+	CPULimit      = "cpuLimit"
+	MemoryUsage   = "memoryUsage"
+	MemoryRequest = "memoryRequest"
+	MemoryLimit   = "memoryLimit"
+)
+
+var quantityNames = []string{CPUUsage, CPURequest, CPULimit, MemoryUsage, MemoryRequest, MemoryLimit}
+
+// Rule is one check of a policy. Exactly one of Require, Max and MinUsageRatio is set.
+type Rule struct {
+	Name     string   `json:"name"`
+	Severity Severity `json:"severity"`
+	Scope    Scope    `json:"scope"`
+	// Namespaces restricts the rule to these namespaces, ExcludeNamespaces skips some
+	Namespaces        []string `json:"namespaces,omitempty"`
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+	// Require lists the requests and limits every container must set (container scope only)
+	Require []string `json:"require,omitempty"`
+	// Max caps quantities summed over the scope, e.g. cpuRequest: "8" or memoryLimit: 16Gi
+	Max map[string]resource.Quantity `json:"max,omitempty"`
+	// MinUsageRatio flags usage/request below the ratio, per resource (cpu, memory), over the pods with metrics;
+	// entries without request are skipped
+	MinUsageRatio map[string]float64 `json:"minUsageRatio,omitempty"`
+}
+
+// Policy is read from a YAML file:
+//
+//	rules:
+//	  - name: memory-limit
+//	    severity: error
+//	    scope: container
+//	    require: [memoryLimit]
+//	  - name: namespace-cpu-budget
+//	    severity: error
+//	    scope: namespace
+//	    excludeNamespaces: [kube-system]
+//	    max: {cpuRequest: "8"}
+//	  - name: low-usage
+//	    severity: warning
+//	    scope: workload
+//	    minUsageRatio: {cpu: 0.2, memory: 0.2}
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Violation is one subject breaking a rule
+type Violation struct {
+	Rule     string
+	Severity Severity
+	Scope    Scope
+	// Namespace and Name identify the subject: container as pod/container, workload as kind/name, empty for a namespace
+	Namespace string
+	Name      string
+	Message   string
+}
+
+// ReadPolicy loads and validates a policy file
+func ReadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &Policy{}
+	if err := yaml.UnmarshalStrict(data, p); err != nil {
+		return nil, fmt.Errorf("cannot decode policy %s: %w", path, err)
+	}
+	if len(p.Rules) == 0 {
+		return nil, fmt.Errorf("no rules in policy %s", path)
+	}
+	for _, rule := range p.Rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("rule %q in %s: %w", rule.Name, path, err)
+		}
+	}
+	return p, nil
+}
+
+func (r Rule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	if r.Severity != SeverityError && r.Severity != SeverityWarning {
+		return fmt.Errorf("severity must be error or warning")
+	}
+	switch r.Scope {
+	case ScopeContainer, ScopePod, ScopeWorkload, ScopeNamespace:
+	default:
+		return fmt.Errorf("scope must be container, pod, workload or namespace")
+	}
+
+	conditions := 0
+	if len(r.Require) > 0 {
+		conditions++
+		if r.Scope != ScopeContainer {
+			return fmt.Errorf("require only applies to the container scope")
+		}
+		for _, name := range r.Require {
+			if name != CPURequest && name != CPULimit && name != MemoryRequest && name != MemoryLimit {
+				return fmt.Errorf("require accepts cpuRequest, cpuLimit, memoryRequest and memoryLimit, not %q", name)
+			}
+		}
+	}
+	if len(r.Max) > 0 {
+		conditions++
+		for name := range r.Max {
+			if !slices.Contains(quantityNames, name) {
+				return fmt.Errorf("unknown quantity %q in max", name)
+			}
+		}
+	}
+	if len(r.MinUsageRatio) > 0 {
+		conditions++
+		for name, ratio := range r.MinUsageRatio {
+			if (name != "cpu" && name != "memory") || ratio <= 0 {
+				return fmt.Errorf("minUsageRatio accepts positive cpu and memory ratios")
+			}
+		}
+	}
+	if conditions != 1 {
+		return fmt.Errorf("exactly one of require, max and minUsageRatio must be set")
+	}
+	return nil
+}
+
+// subject is a container, pod, workload or namespace the rules are evaluated on
+type subject struct {
+	namespace string
+	name      string
+	total     Quantities
+	// measured sums the pods with metrics only: usage/request ratios are computed on it, so the
+	// requests of pods without usage (pending, just started) do not lower them
+	measured Quantities
+}
+
+// Check evaluates every rule against the cluster. Violations are ordered by severity (errors first),
+// rule, namespace and name.
+func (p *Policy) Check(cluster *Cluster) []Violation {
+	var violations []Violation
+	for _, rule := range p.Rules {
+		for _, s := range subjects(cluster, rule) {
+			for _, message := range rule.evaluate(s) {
+				violations = append(violations, Violation{
+					Rule:      rule.Name,
+					Severity:  rule.Severity,
+					Scope:     rule.Scope,
+					Namespace: s.namespace,
+					Name:      s.name,
+					Message:   message,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		a, b := violations[i], violations[j]
+		if a.Severity != b.Severity {
+			return a.Severity == SeverityError
+		}
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return violations
+}
+
+// subjects lists the entries of the rule scope in the namespaces it applies to. Pods and containers
+// are the running ones with metrics; init containers have completed and ephemeral ones cannot set resources.
+func subjects(cluster *Cluster, rule Rule) []subject {
+	var result []subject
+	for _, ns := range cluster.Namespaces {
+		if len(rule.Namespaces) > 0 && !slices.Contains(rule.Namespaces, ns.Name) {
+			continue
+		}
+		if slices.Contains(rule.ExcludeNamespaces, ns.Name) {
+			continue
+		}
+		switch rule.Scope {
+		case ScopeNamespace:
+			if len(ns.Pods) > 0 {
+				result = append(result, subject{namespace: ns.Name, total: ns.Total(), measured: measuredTotal(ns.Pods)})
+			}
+		case ScopeWorkload:
+			for _, w := range ns.Workloads() {
+				result = append(result, subject{namespace: ns.Name, name: w.Workload.String(), total: w.Total(), measured: measuredTotal(w.Pods)})
+			}
+		case ScopePod:
+			for _, pod := range ns.Pods {
+				if pod.HasMetrics && !pod.Completed() {
+					total := pod.Total()
+					result = append(result, subject{namespace: ns.Name, name: pod.Name, total: total, measured: total})
+				}
+			}
+		case ScopeContainer:
+			for _, pod := range ns.Pods {
+				if !pod.HasMetrics || pod.Completed() {
+					continue
+				}
+				for _, c := range pod.Containers {
+					if c.Kind == ContainerKindInit || c.Kind == ContainerKindEphemeral {
+						continue
+					}
+					result = append(result, subject{namespace: ns.Name, name: pod.Name + "/" + c.Name, total: c.Quantities, measured: c.Quantities})
+				}
+			}
+		}
+	}
+	return result
+}
+
+// measuredTotal sums the quantities of the pods with metrics
+func measuredTotal(pods []*Pod) Quantities {
+	var total Quantities
+	for _, p := range pods {
+		if p.HasMetrics {
+			total.Add(p.Total())
+		}
+	}
+	return total
+}
+
+// evaluate returns one message per broken condition of the rule
+func (r Rule) evaluate(s subject) []string {
+	q := s.total
+	var messages []string
+	missing := q.Set.Missing()
+	for _, name := range r.Require {
+		if slices.Contains(missing, name) {
+			messages = append(messages, fmt.Sprintf("%s not set", name))
+		}
+	}
+	for _, name := range quantityNames {
+		limit, ok := r.Max[name]
+		if !ok {
+			continue
+		}
+		if value := quantityValue(q, name); value > quantityLimit(name, limit) {
+			messages = append(messages, fmt.Sprintf("%s %s above %s", name, formatQuantity(name, value), limit.String()))
+		}
+	}
+	for _, resourceName := range []string{"cpu", "memory"} {
+		minRatio, ok := r.MinUsageRatio[resourceName]
+		if !ok {
+			continue
+		}
+		usage, request := s.measured.Usage.CPU, s.measured.Request.CPU
+		if resourceName == "memory" {
+			usage, request = s.measured.Usage.Memory, s.measured.Request.Memory
+		}
+		if ratio, ok := ratio(usage, request); ok && ratio < minRatio {
+			messages = append(messages, fmt.Sprintf("%s usage/request %.1f %% below %.1f %%", resourceName, ratio*100, minRatio*100))
+		}
+	}
+	return messages
+}
+
+// quantityValue returns the named quantity: CPU in millicores, memory in bytes
+func quantityValue(q Quantities, name string) int64 {
+	switch name {
+	case CPUUsage:
+		return q.Usage.CPU
+	case CPURequest:
+		return q.Request.CPU
+	case CPULimit:
+		return q.Limit.CPU
+	case MemoryUsage:
+		return q.Usage.Memory
+	case MemoryRequest:
+		return q.Request.Memory
+	default:
+		return q.Limit.Memory
+	}
+}
+
+// quantityLimit converts a policy quantity to the unit of quantityValue
+func quantityLimit(name string, limit resource.Quantity) int64 {
+	if name == CPUUsage || name == CPURequest || name == CPULimit {
+		return limit.MilliValue()
+	}
+	return limit.Value()
+}
+
+// formatQuantity formats a value of quantityValue as a Kubernetes quantity
+func formatQuantity(name string, value int64) string {
+	if name == CPUUsage || name == CPURequest || name == CPULimit {
+		return resource.NewMilliQuantity(value, resource.DecimalSI).String()
+	}
+	return resource.NewQuantity(value, resource.BinarySI).String()
+}
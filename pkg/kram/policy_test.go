@@ -0,0 +1,209 @@
+package kram
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	const mi = 1 << 20
+	api := Workload{Kind: "Deployment", Name: "api"}
+
+	tests := []struct {
+		name       string
+		namespaces []*Namespace
+		rules      []Rule
+		want       []Violation
+	}{
+		{
+			name: "require flags each container not setting the quantity, an explicit 0 is set",
+			namespaces: []*Namespace{{Name: "web", Pods: []*Pod{
+				{Name: "api-1", Namespace: "web", HasMetrics: true, Phase: "Running", Containers: []*Container{
+					{Name: "api", Quantities: Quantities{Limit: Resources{CPU: 200, Memory: 256 * mi}, Set: Specified{CPULimit: true, MemoryLimit: true}}},
+					{Name: "proxy", Quantities: Quantities{Limit: Resources{CPU: 100}, Set: Specified{CPULimit: true}}},
+					{Name: "unbounded", Quantities: Quantities{Set: Specified{CPULimit: true, MemoryLimit: true}}},
+				}},
+			}}},
+			rules: []Rule{{Name: "memory-limit", Severity: SeverityError, Scope: ScopeContainer, Require: []string{CPULimit, MemoryLimit}}},
+			want: []Violation{
+				{Rule: "memory-limit", Severity: SeverityError, Scope: ScopeContainer, Namespace: "web", Name: "api-1/proxy", Message: "memoryLimit not set"},
+			},
+		},
+		{
+			name: "require skips init and ephemeral containers, pods without metrics and completed pods",
+			namespaces: []*Namespace{{Name: "web", Pods: []*Pod{
+				{Name: "api-1", Namespace: "web", HasMetrics: true, Phase: "Running", Containers: []*Container{
+					{Name: "migrate", Kind: ContainerKindInit},
+					{Name: "mesh", Kind: ContainerKindSidecar},
+					{Name: "api", Kind: ContainerKindApp, Quantities: Quantities{Set: Specified{MemoryLimit: true}}},
+					{Name: "debugger", Kind: ContainerKindEphemeral},
+				}},
+				{Name: "api-2", Namespace: "web", Phase: "Pending", Containers: []*Container{{Name: "api", Kind: ContainerKindApp}}},
+				{Name: "report-x", Namespace: "web", HasMetrics: true, Phase: "Succeeded", Containers: []*Container{{Name: "report", Kind: ContainerKindApp}}},
+			}}},
+			rules: []Rule{{Name: "memory-limit", Severity: SeverityError, Scope: ScopeContainer, Require: []string{MemoryLimit}}},
+			want: []Violation{
+				{Rule: "memory-limit", Severity: SeverityError, Scope: ScopeContainer, Namespace: "web", Name: "api-1/mesh", Message: "memoryLimit not set"},
+			},
+		},
+		{
+			name: "namespaces and excluded namespaces",
+			namespaces: []*Namespace{
+				{Name: "kube-system", Pods: []*Pod{{Name: "dns", Namespace: "kube-system", HasMetrics: true, Containers: []*Container{{Name: "dns"}}}}},
+				{Name: "shop", Pods: []*Pod{{Name: "cart", Namespace: "shop", HasMetrics: true, Containers: []*Container{{Name: "cart"}}}}},
+				{Name: "web", Pods: []*Pod{{Name: "api-1", Namespace: "web", HasMetrics: true, Containers: []*Container{{Name: "api"}}}}},
+			},
+			rules: []Rule{
+				{Name: "cpu-request", Severity: SeverityError, Scope: ScopeContainer, ExcludeNamespaces: []string{"kube-system"}, Require: []string{CPURequest}},
+				{Name: "memory-request", Severity: SeverityError, Scope: ScopeContainer, Namespaces: []string{"web"}, Require: []string{MemoryRequest}},
+			},
+			want: []Violation{
+				{Rule: "cpu-request", Severity: SeverityError, Scope: ScopeContainer, Namespace: "shop", Name: "cart/cart", Message: "cpuRequest not set"},
+				{Rule: "cpu-request", Severity: SeverityError, Scope: ScopeContainer, Namespace: "web", Name: "api-1/api", Message: "cpuRequest not set"},
+				{Rule: "memory-request", Severity: SeverityError, Scope: ScopeContainer, Namespace: "web", Name: "api-1/api", Message: "memoryRequest not set"},
+			},
+		},
+		{
+			name: "max applies to the namespace total",
+			namespaces: []*Namespace{
+				{Name: "idle"},
+				{Name: "shop", Pods: []*Pod{
+					{Name: "cart", Namespace: "shop", Containers: []*Container{{Name: "cart", Quantities: Quantities{Request: Resources{CPU: 200, Memory: 512 * mi}}}}},
+				}},
+				{Name: "web", Pods: []*Pod{
+					{Name: "api-1", Namespace: "web", Containers: []*Container{{Name: "api", Quantities: Quantities{Request: Resources{CPU: 300, Memory: 512 * mi}}}}},
+					{Name: "api-2", Namespace: "web", Containers: []*Container{{Name: "api", Quantities: Quantities{Request: Resources{CPU: 300, Memory: 512 * mi}}}}},
+				}},
+			},
+			rules: []Rule{{Name: "budget", Severity: SeverityError, Scope: ScopeNamespace, Max: map[string]resource.Quantity{
+				CPURequest:    resource.MustParse("500m"),
+				MemoryRequest: resource.MustParse("1Gi"),
+			}}},
+			want: []Violation{
+				{Rule: "budget", Severity: SeverityError, Scope: ScopeNamespace, Namespace: "web", Message: "cpuRequest 600m above 500m"},
+			},
+		},
+		{
+			name: "minUsageRatio sums the replicas and skips entries without request",
+			namespaces: []*Namespace{{Name: "web", Pods: []*Pod{
+				{Name: "api-1", Namespace: "web", Workload: api, HasMetrics: true, Containers: []*Container{
+					{Name: "api", Quantities: Quantities{Usage: Resources{CPU: 4}, Request: Resources{CPU: 100}}},
+				}},
+				{Name: "api-2", Namespace: "web", Workload: api, HasMetrics: true, Containers: []*Container{
+					{Name: "api", Quantities: Quantities{Usage: Resources{CPU: 6}, Request: Resources{CPU: 100}}},
+				}},
+				{Name: "report", Namespace: "web", Workload: Workload{Kind: "Pod", Name: "report"}, HasMetrics: true, Containers: []*Container{
+					{Name: "report", Quantities: Quantities{Usage: Resources{CPU: 1}}},
+				}},
+			}}},
+			rules: []Rule{{Name: "low-usage", Severity: SeverityWarning, Scope: ScopeWorkload, MinUsageRatio: map[string]float64{"cpu": 0.2}}},
+			want: []Violation{
+				{Rule: "low-usage", Severity: SeverityWarning, Scope: ScopeWorkload, Namespace: "web", Name: "deployment/api", Message: "cpu usage/request 5.0 % below 20.0 %"},
+			},
+		},
+		{
+			name: "minUsageRatio counts the pods with metrics only",
+			namespaces: []*Namespace{
+				{Name: "shop", Pods: []*Pod{
+					{Name: "cart", Namespace: "shop", Workload: Workload{Kind: "Deployment", Name: "cart"}, HasMetrics: true, Phase: "Running", Containers: []*Container{
+						{Name: "cart", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 10}, Request: Resources{CPU: 100}}},
+					}},
+				}},
+				{Name: "web", Pods: []*Pod{
+					{Name: "api-1", Namespace: "web", Workload: api, HasMetrics: true, Phase: "Running", Containers: []*Container{
+						{Name: "api", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 30}, Request: Resources{CPU: 100}}},
+					}},
+					// Pending: its request would bring the ratio down to 15 %
+					{Name: "api-2", Namespace: "web", Workload: api, Phase: "Pending", Containers: []*Container{
+						{Name: "api", Kind: ContainerKindApp, Quantities: Quantities{Request: Resources{CPU: 100}}},
+					}},
+				}},
+			},
+			rules: []Rule{
+				{Name: "low-usage", Severity: SeverityWarning, Scope: ScopeNamespace, MinUsageRatio: map[string]float64{"cpu": 0.2}},
+				{Name: "low-workload-usage", Severity: SeverityWarning, Scope: ScopeWorkload, MinUsageRatio: map[string]float64{"cpu": 0.2}},
+			},
+			want: []Violation{
+				{Rule: "low-usage", Severity: SeverityWarning, Scope: ScopeNamespace, Namespace: "shop", Message: "cpu usage/request 10.0 % below 20.0 %"},
+				{Rule: "low-workload-usage", Severity: SeverityWarning, Scope: ScopeWorkload, Namespace: "shop", Name: "deployment/cart", Message: "cpu usage/request 10.0 % below 20.0 %"},
+			},
+		},
+		{
+			name: "pod scope skips completed pods",
+			namespaces: []*Namespace{{Name: "web", Pods: []*Pod{
+				{Name: "api-1", Namespace: "web", HasMetrics: true, Phase: "Running", Containers: []*Container{
+					{Name: "api", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 150}}},
+				}},
+				{Name: "report-x", Namespace: "web", HasMetrics: true, Phase: "Failed", Containers: []*Container{
+					{Name: "report", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 300}}},
+				}},
+			}}},
+			rules: []Rule{{Name: "pod-usage", Severity: SeverityError, Scope: ScopePod, Max: map[string]resource.Quantity{CPUUsage: resource.MustParse("100m")}}},
+			want: []Violation{
+				{Rule: "pod-usage", Severity: SeverityError, Scope: ScopePod, Namespace: "web", Name: "api-1", Message: "cpuUsage 150m above 100m"},
+			},
+		},
+		{
+			name: "errors come before warnings",
+			namespaces: []*Namespace{{Name: "web", Pods: []*Pod{
+				{Name: "api-1", Namespace: "web", HasMetrics: true, Containers: []*Container{
+					{Name: "api", Quantities: Quantities{Usage: Resources{CPU: 15}, Request: Resources{CPU: 500}}},
+				}},
+				// Without metrics the pod is not evaluated
+				{Name: "api-2", Namespace: "web", Containers: []*Container{
+					{Name: "api", Quantities: Quantities{Request: Resources{CPU: 500}}},
+				}},
+			}}},
+			rules: []Rule{
+				{Name: "low-usage", Severity: SeverityWarning, Scope: ScopePod, MinUsageRatio: map[string]float64{"cpu": 0.2}},
+				{Name: "pod-budget", Severity: SeverityError, Scope: ScopePod, Max: map[string]resource.Quantity{CPURequest: resource.MustParse("400m")}},
+			},
+			want: []Violation{
+				{Rule: "pod-budget", Severity: SeverityError, Scope: ScopePod, Namespace: "web", Name: "api-1", Message: "cpuRequest 500m above 400m"},
+				{Rule: "low-usage", Severity: SeverityWarning, Scope: ScopePod, Namespace: "web", Name: "api-1", Message: "cpu usage/request 3.0 % below 20.0 %"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, rule := range tt.rules {
+				if err := rule.validate(); err != nil {
+					t.Fatalf("rule %q: %v", rule.Name, err)
+				}
+			}
+			policy := &Policy{Rules: tt.rules}
+			if got := policy.Check(&Cluster{Namespaces: tt.namespaces}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{name: "valid", rule: Rule{Name: "r", Severity: SeverityError, Scope: ScopeContainer, Require: []string{CPURequest}}},
+		{name: "missing name", rule: Rule{Severity: SeverityError, Scope: ScopeContainer, Require: []string{CPURequest}}, wantErr: true},
+		{name: "unknown severity", rule: Rule{Name: "r", Severity: "info", Scope: ScopeContainer, Require: []string{CPURequest}}, wantErr: true},
+		{name: "require outside the container scope", rule: Rule{Name: "r", Severity: SeverityError, Scope: ScopePod, Require: []string{CPURequest}}, wantErr: true},
+		{name: "usage cannot be required", rule: Rule{Name: "r", Severity: SeverityError, Scope: ScopeContainer, Require: []string{CPUUsage}}, wantErr: true},
+		{name: "unknown quantity", rule: Rule{Name: "r", Severity: SeverityWarning, Scope: ScopePod, Max: map[string]resource.Quantity{"cpu": resource.MustParse("1")}}, wantErr: true},
+		{name: "zero ratio", rule: Rule{Name: "r", Severity: SeverityWarning, Scope: ScopePod, MinUsageRatio: map[string]float64{"cpu": 0}}, wantErr: true},
+		{name: "two conditions", rule: Rule{Name: "r", Severity: SeverityWarning, Scope: ScopeContainer, Require: []string{CPURequest}, MinUsageRatio: map[string]float64{"cpu": 0.2}}, wantErr: true},
+		{name: "no condition", rule: Rule{Name: "r", Severity: SeverityWarning, Scope: ScopeContainer}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rule.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package kram
+
+import (
+	"math"
+	"sort"
+)
+
+// ============================================================
+// RECOMMEND — rightsizing from observed usage
+// ============================================================
+
+// Provisioning classifies the current request of a container against its recommendation
+type Provisioning string
+
+const (
+	ProvisioningOK   Provisioning = "ok"
+	OverProvisioned  Provisioning = "over"
+	UnderProvisioned Provisioning = "under"
+)
+
+const (
+	// A request above overProvisionedRatio times the recommendation is flagged as over-provisioned
+	overProvisionedRatio = 1.5
+	// Usage above nearLimitRatio of the limit is flagged as under-provisioned (throttling, OOM kill)
+	nearLimitRatio = 0.9
+
+	minCPURequest    = 5                // millicores
+	minMemoryRequest = 16 * 1024 * 1024 // bytes
+	cpuStep          = 5                // millicores
+	memoryStep       = 1024 * 1024      // bytes
+)
+
+// RecommendOptions tunes how recommendations are derived from usage
+type RecommendOptions struct {
+	// Headroom is added on top of the peak usage, 0.3 for 30 %
+	Headroom float64
+	// LimitRatio sets limits to LimitRatio times the recommended request; 0 recommends no limit
+	LimitRatio float64
+}
+
+// Recommendation is the proposed sizing of one container of a workload
+type Recommendation struct {
+	Namespace string
+	Workload  Workload
+	Container string
+	// Kind tells app containers from sidecars, which are patched under initContainers
+	Kind ContainerKind
+	// Pods is the number of replicas the usage was observed on
+	Pods int
+	// Current holds the peak usage across replicas (p95 of each replica when sampled)
+	// and the highest request and limit
+	Current     Quantities
+	Request     Resources
+	Limit       Resources
+	CPU, Memory Provisioning
+}
+
+// Recommend proposes requests and limits for every container and sidecar of the running pods with metrics, aggregated by workload.
+// Recommendations are sorted by namespace, workload and container.
+func Recommend(cluster *Cluster, opts RecommendOptions) []Recommendation {
+	type key struct {
+		namespace string
+		workload  Workload
+		container string
+	}
+	byKey := make(map[key]*Recommendation)
+
+	for _, ns := range cluster.Namespaces {
+		for _, pod := range ns.Pods {
+			if !pod.HasMetrics || pod.Completed() {
+				continue
+			}
+			for _, container := range pod.Containers {
+				// Init containers have completed and ephemeral ones cannot set resources
+				if container.Kind == ContainerKindInit || container.Kind == ContainerKindEphemeral {
+					continue
+				}
+				k := key{pod.Namespace, pod.Workload, container.Name}
+				rec, ok := byKey[k]
+				if !ok {
+					rec = &Recommendation{Namespace: pod.Namespace, Workload: pod.Workload, Container: container.Name, Kind: container.Kind}
+					byKey[k] = rec
+				}
+				rec.Pods++
+				usage := container.Usage
+				if len(container.Samples) > 1 {
+					usage = SampleStats(container.Samples).P95
+				}
+				rec.Current.Usage = maxResources(rec.Current.Usage, usage)
+				rec.Current.Request = maxResources(rec.Current.Request, container.Request)
+				rec.Current.Limit = maxResources(rec.Current.Limit, container.Limit)
+				rec.Current.Set.Merge(container.Set)
+			}
+		}
+	}
+
+	recs := make([]Recommendation, 0, len(byKey))
+	for _, rec := range byKey {
+		rec.Request = Resources{
+			CPU:    recommendValue(rec.Current.Usage.CPU, opts.Headroom, minCPURequest, cpuStep),
+			Memory: recommendValue(rec.Current.Usage.Memory, opts.Headroom, minMemoryRequest, memoryStep),
+		}
+		if opts.LimitRatio > 0 {
+			rec.Limit = Resources{
+				CPU:    roundUp(int64(float64(rec.Request.CPU)*opts.LimitRatio), cpuStep),
+				Memory: roundUp(int64(float64(rec.Request.Memory)*opts.LimitRatio), memoryStep),
+			}
+		}
+		rec.CPU = provisioning(rec.Current.Usage.CPU, rec.Current.Request.CPU, rec.Current.Limit.CPU, rec.Request.CPU)
+		rec.Memory = provisioning(rec.Current.Usage.Memory, rec.Current.Request.Memory, rec.Current.Limit.Memory, rec.Request.Memory)
+		recs = append(recs, *rec)
+	}
+
+	sort.Slice(recs, func(i, j int) bool {
+		a, b := recs[i], recs[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Workload != b.Workload {
+			return a.Workload.String() < b.Workload.String()
+		}
+		return a.Container < b.Container
+	})
+	return recs
+}
+
+// provisioning compares the current request and limit with the usage and the recommended request
+func provisioning(usage, request, limit, recommended int64) Provisioning {
+	switch {
+	case request == 0 || usage > request:
+		return UnderProvisioned
+	case limit > 0 && float64(usage) >= float64(limit)*nearLimitRatio:
+		return UnderProvisioned
+	case float64(request) > float64(recommended)*overProvisionedRatio:
+		return OverProvisioned
+	default:
+		return ProvisioningOK
+	}
+}
+
+// recommendValue adds headroom to usage, rounded up to step and never below min
+func recommendValue(usage int64, headroom float64, min, step int64) int64 {
+	v := roundUp(int64(math.Ceil(float64(usage)*(1+headroom))), step)
+	if v < min {
+		return min
+	}
+	return v
+}
+
+// roundUp rounds v up to a multiple of step
+func roundUp(v, step int64) int64 {
+	return (v + step - 1) / step * step
+}
+
+func maxResources(a, b Resources) Resources {
+	return Resources{CPU: max(a.CPU, b.CPU), Memory: max(a.Memory, b.Memory)}
+}
@@ -0,0 +1,172 @@
+package kram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecommend(t *testing.T) {
+	const mi = 1 << 20
+	web := Workload{Kind: "Deployment", Name: "web"}
+	batch := Workload{Kind: "Job", Name: "batch"}
+
+	tests := []struct {
+		name string
+		pods []*Pod
+		opts RecommendOptions
+		want []Recommendation
+	}{
+		{
+			name: "replicas are sized on their peak usage",
+			pods: []*Pod{
+				{Name: "web-a", Namespace: "shop", Workload: web, HasMetrics: true, Containers: []*Container{
+					{Name: "web", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 80, Memory: 10 * mi}, Request: Resources{CPU: 50, Memory: 128 * mi}, Limit: Resources{CPU: 100, Memory: 256 * mi}}},
+				}},
+				{Name: "web-b", Namespace: "shop", Workload: web, HasMetrics: true, Containers: []*Container{
+					{Name: "web", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 20, Memory: 40 * mi}, Request: Resources{CPU: 50, Memory: 128 * mi}, Limit: Resources{CPU: 100, Memory: 256 * mi}}},
+				}},
+			},
+			opts: RecommendOptions{Headroom: 0.2, LimitRatio: 2},
+			want: []Recommendation{{
+				Namespace: "shop", Workload: web, Container: "web", Kind: ContainerKindApp, Pods: 2,
+				Current: Quantities{Usage: Resources{CPU: 80, Memory: 40 * mi}, Request: Resources{CPU: 50, Memory: 128 * mi}, Limit: Resources{CPU: 100, Memory: 256 * mi}},
+				Request: Resources{CPU: 100, Memory: 48 * mi},
+				Limit:   Resources{CPU: 200, Memory: 96 * mi},
+				CPU:     UnderProvisioned, Memory: OverProvisioned,
+			}},
+		},
+		{
+			name: "sampled replica is sized on its p95",
+			pods: []*Pod{
+				{Name: "web-a", Namespace: "shop", Workload: web, HasMetrics: true, Containers: []*Container{
+					{Name: "web", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 20, Memory: 10 * mi}, Request: Resources{CPU: 200, Memory: 32 * mi}}, Samples: []Resources{
+						{CPU: 10, Memory: 10 * mi}, {CPU: 20, Memory: 10 * mi}, {CPU: 30, Memory: 10 * mi}, {CPU: 40, Memory: 10 * mi}, {CPU: 50, Memory: 10 * mi},
+						{CPU: 60, Memory: 10 * mi}, {CPU: 70, Memory: 10 * mi}, {CPU: 80, Memory: 10 * mi}, {CPU: 90, Memory: 10 * mi}, {CPU: 100, Memory: 10 * mi},
+						{CPU: 110, Memory: 10 * mi}, {CPU: 120, Memory: 10 * mi}, {CPU: 130, Memory: 10 * mi}, {CPU: 140, Memory: 10 * mi}, {CPU: 150, Memory: 10 * mi},
+						{CPU: 160, Memory: 10 * mi}, {CPU: 170, Memory: 10 * mi}, {CPU: 180, Memory: 10 * mi}, {CPU: 190, Memory: 10 * mi}, {CPU: 900, Memory: 10 * mi},
+					}},
+				}},
+			},
+			want: []Recommendation{{
+				Namespace: "shop", Workload: web, Container: "web", Kind: ContainerKindApp, Pods: 1,
+				Current: Quantities{Usage: Resources{CPU: 190, Memory: 10 * mi}, Request: Resources{CPU: 200, Memory: 32 * mi}},
+				Request: Resources{CPU: 190, Memory: minMemoryRequest},
+				CPU:     ProvisioningOK, Memory: OverProvisioned,
+			}},
+		},
+		{
+			name: "init and ephemeral containers, pods without metrics and completed pods are left out",
+			pods: []*Pod{
+				{Name: "web-a", Namespace: "shop", Workload: web, HasMetrics: true, Containers: []*Container{
+					{Name: "migrate", Kind: ContainerKindInit, Quantities: Quantities{Request: Resources{CPU: 500, Memory: 64 * mi}}},
+					{Name: "web", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 40, Memory: 20 * mi}, Request: Resources{CPU: 50, Memory: 24 * mi}}},
+					{Name: "debugger", Kind: ContainerKindEphemeral, Quantities: Quantities{Usage: Resources{CPU: 1, Memory: 8 * mi}}},
+				}},
+				{Name: "web-b", Namespace: "shop", Workload: web, Containers: []*Container{
+					{Name: "web", Kind: ContainerKindApp, Quantities: Quantities{Request: Resources{CPU: 50, Memory: 24 * mi}}},
+				}},
+				{Name: "web-c", Namespace: "shop", Workload: web, HasMetrics: true, Phase: "Failed", Containers: []*Container{
+					{Name: "web", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 900, Memory: 200 * mi}, Request: Resources{CPU: 50, Memory: 24 * mi}}},
+				}},
+			},
+			want: []Recommendation{{
+				Namespace: "shop", Workload: web, Container: "web", Kind: ContainerKindApp, Pods: 1,
+				Current: Quantities{Usage: Resources{CPU: 40, Memory: 20 * mi}, Request: Resources{CPU: 50, Memory: 24 * mi}},
+				Request: Resources{CPU: 40, Memory: 20 * mi},
+				CPU:     ProvisioningOK, Memory: ProvisioningOK,
+			}},
+		},
+		{
+			name: "sidecars are recommended with their kind",
+			pods: []*Pod{
+				{Name: "web-a", Namespace: "shop", Workload: web, HasMetrics: true, Containers: []*Container{
+					{Name: "mesh", Kind: ContainerKindSidecar, Quantities: Quantities{Usage: Resources{CPU: 10, Memory: 30 * mi}, Request: Resources{CPU: 10, Memory: 32 * mi}}},
+					{Name: "web", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 40, Memory: 20 * mi}, Request: Resources{CPU: 50, Memory: 24 * mi}}},
+				}},
+			},
+			want: []Recommendation{
+				{
+					Namespace: "shop", Workload: web, Container: "mesh", Kind: ContainerKindSidecar, Pods: 1,
+					Current: Quantities{Usage: Resources{CPU: 10, Memory: 30 * mi}, Request: Resources{CPU: 10, Memory: 32 * mi}},
+					Request: Resources{CPU: 10, Memory: 30 * mi},
+					CPU:     ProvisioningOK, Memory: ProvisioningOK,
+				},
+				{
+					Namespace: "shop", Workload: web, Container: "web", Kind: ContainerKindApp, Pods: 1,
+					Current: Quantities{Usage: Resources{CPU: 40, Memory: 20 * mi}, Request: Resources{CPU: 50, Memory: 24 * mi}},
+					Request: Resources{CPU: 40, Memory: 20 * mi},
+					CPU:     ProvisioningOK, Memory: ProvisioningOK,
+				},
+			},
+		},
+		{
+			name: "minimum request and no limit",
+			pods: []*Pod{
+				{Name: "batch-x", Namespace: "shop", Workload: batch, HasMetrics: true, Containers: []*Container{
+					{Name: "worker", Kind: ContainerKindApp, Quantities: Quantities{Usage: Resources{CPU: 1, Memory: mi}}},
+				}},
+			},
+			want: []Recommendation{{
+				Namespace: "shop", Workload: batch, Container: "worker", Kind: ContainerKindApp, Pods: 1,
+				Current: Quantities{Usage: Resources{CPU: 1, Memory: mi}},
+				Request: Resources{CPU: minCPURequest, Memory: minMemoryRequest},
+				CPU:     UnderProvisioned, Memory: UnderProvisioned,
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &Cluster{Namespaces: []*Namespace{{Name: "shop", Pods: tt.pods}}}
+			if got := Recommend(cluster, tt.opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Recommend() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecommendOrder(t *testing.T) {
+	pod := func(namespace string, workload Workload, containers ...string) *Pod {
+		p := &Pod{Name: workload.Name, Namespace: namespace, Workload: workload, HasMetrics: true}
+		for _, name := range containers {
+			p.Containers = append(p.Containers, &Container{Name: name, Kind: ContainerKindApp})
+		}
+		return p
+	}
+	cluster := &Cluster{Namespaces: []*Namespace{
+		{Name: "web", Pods: []*Pod{pod("web", Workload{Kind: "StatefulSet", Name: "db"}, "db"), pod("web", Workload{Kind: "Deployment", Name: "api"}, "proxy", "api")}},
+		{Name: "batch", Pods: []*Pod{pod("batch", Workload{Kind: "Job", Name: "report"}, "report")}},
+	}}
+	want := []string{"batch job/report report", "web deployment/api api", "web deployment/api proxy", "web statefulset/db db"}
+
+	recs := Recommend(cluster, RecommendOptions{})
+	var got []string
+	for _, rec := range recs {
+		got = append(got, rec.Namespace+" "+rec.Workload.String()+" "+rec.Container)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Recommend() order = %q, want %q", got, want)
+	}
+}
+
+func TestProvisioning(t *testing.T) {
+	tests := []struct {
+		name                                  string
+		usage, request, limit, recommendation int64
+		want                                  Provisioning
+	}{
+		{name: "no request", usage: 10, recommendation: 15, want: UnderProvisioned},
+		{name: "usage above request", usage: 120, request: 100, recommendation: 150, want: UnderProvisioned},
+		{name: "usage close to the limit", usage: 95, request: 100, limit: 100, recommendation: 115, want: UnderProvisioned},
+		{name: "request well above recommendation", usage: 10, request: 100, recommendation: 15, want: OverProvisioned},
+		{name: "sized", usage: 80, request: 100, limit: 200, recommendation: 100, want: ProvisioningOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := provisioning(tt.usage, tt.request, tt.limit, tt.recommendation); got != tt.want {
+				t.Errorf("provisioning() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package kram
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// SAMPLE — usage over a time window
+// ============================================================
+
+// UsageStats summarises the usage readings of a sampling window
+type UsageStats struct {
+	Min Resources
+	Avg Resources
+	P50 Resources
+	P95 Resources
+	Max Resources
+}
+
+// SampleStats computes the statistics of a series of readings, CPU and memory independently
+func SampleStats(samples []Resources) UsageStats {
+	if len(samples) == 0 {
+		return UsageStats{}
+	}
+	cpu := make([]int64, len(samples))
+	memory := make([]int64, len(samples))
+	var sum Resources
+	for i, s := range samples {
+		cpu[i], memory[i] = s.CPU, s.Memory
+		sum.Add(s)
+	}
+	sort.Slice(cpu, func(i, j int) bool { return cpu[i] < cpu[j] })
+	sort.Slice(memory, func(i, j int) bool { return memory[i] < memory[j] })
+
+	n := int64(len(samples))
+	return UsageStats{
+		Min: Resources{CPU: cpu[0], Memory: memory[0]},
+		Avg: Resources{CPU: sum.CPU / n, Memory: sum.Memory / n},
+		P50: Resources{CPU: percentile(cpu, 50), Memory: percentile(memory, 50)},
+		P95: Resources{CPU: percentile(cpu, 95), Memory: percentile(memory, 95)},
+		Max: Resources{CPU: cpu[len(cpu)-1], Memory: memory[len(memory)-1]},
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []int64, p int) int64 {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// AddSamples sums src into dst reading by reading and returns dst
+func AddSamples(dst []Resources, src []Resources) []Resources {
+	for len(dst) < len(src) {
+		dst = append(dst, Resources{})
+	}
+	for i, s := range src {
+		dst[i].Add(s)
+	}
+	return dst
+}
+
+// UsageSamples sums the readings of every container of the pod; nil without sampling
+func (p *Pod) UsageSamples() []Resources {
+	var samples []Resources
+	for _, c := range p.Containers {
+		samples = AddSamples(samples, c.Samples)
+	}
+	return samples
+}
+
+// UsageSamples sums the readings of every pod of the namespace; nil without sampling
+func (ns *Namespace) UsageSamples() []Resources {
+	var samples []Resources
+	for _, p := range ns.Pods {
+		samples = AddSamples(samples, p.UsageSamples())
+	}
+	return samples
+}
+
+// UsageSamples sums the readings of every namespace; nil without sampling
+func (c *Cluster) UsageSamples() []Resources {
+	var samples []Resources
+	for _, ns := range c.Namespaces {
+		samples = AddSamples(samples, ns.UsageSamples())
+	}
+	return samples
+}
+
+// Sample collects like Collect, then reads pod metrics again every interval until duration has elapsed.
+// Pods and containers are those of the first reading. A reading missing at a tick (metrics-server
+// restarting, pod terminating) repeats the previous one so that every series stays aligned.
+// Container usage is then the average of its readings. Errors repeated at every tick are reported once.
+// When ctx is cancelled, the readings taken so far are averaged and ctx.Err() is reported.
+func (c *Collector) Sample(ctx context.Context, namespaces []string, duration, interval time.Duration) (*Cluster, []error) {
+	cluster, errorsList := c.Collect(ctx, namespaces)
+
+	ticks := int(duration / interval)
+	if ticks < 1 {
+		ticks = 1
+	}
+	for _, pod := range cluster.Pods() {
+		for _, container := range pod.Containers {
+			container.Samples = make([]Resources, 1, ticks)
+			container.Samples[0] = container.Usage
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, err := range errorsList {
+		seen[err.Error()] = true
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	readings := 1
+	for readings < ticks && ctx.Err() == nil {
+		select {
+		case <-ctx.Done():
+			continue
+		case <-ticker.C:
+		}
+
+		for _, err := range c.sampleOnce(ctx, cluster) {
+			if !seen[err.Error()] {
+				seen[err.Error()] = true
+				errorsList = append(errorsList, err)
+			}
+		}
+		readings++
+		if c.Progress != nil {
+			c.Progress()
+		}
+	}
+	cluster.Samples = readings
+
+	for _, pod := range cluster.Pods() {
+		for _, container := range pod.Containers {
+			container.Usage = SampleStats(container.Samples).Avg
+		}
+	}
+	cluster.CollectedAt = time.Now()
+	if err := ctx.Err(); err != nil {
+		errorsList = append(errorsList, err)
+	}
+	return cluster, errorsList
+}
+
+// sampleOnce appends one usage reading to every container of the cluster
+func (c *Collector) sampleOnce(ctx context.Context, cluster *Cluster) []error {
+	var errorsList []error
+
+	// Thread-safe synchronization for parallel processing
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(len(cluster.Namespaces))
+
+	for _, ns := range cluster.Namespaces {
+		go func(ns *Namespace) {
+			defer wg.Done()
+			if len(ns.Pods) == 0 {
+				return
+			}
+
+			metricsMap := c.getNamespacePodMetricsMap(ctx, ns.Name, &errorsList, &mu)
+			for _, pod := range ns.Pods {
+				usage := make(map[string]Resources)
+				if podMetrics, ok := metricsMap[pod.Name]; ok {
+					for _, containerMetrics := range podMetrics.Containers {
+						usage[containerMetrics.Name] = Resources{
+							CPU:    containerMetrics.Usage.Cpu().MilliValue(),
+							Memory: containerMetrics.Usage.Memory().Value(),
+						}
+					}
+				}
+				for _, container := range pod.Containers {
+					reading, ok := usage[container.Name]
+					if !ok {
+						reading = container.Samples[len(container.Samples)-1]
+					}
+					container.Samples = append(container.Samples, reading)
+				}
+			}
+		}(ns)
+	}
+
+	wg.Wait()
+	return errorsList
+}
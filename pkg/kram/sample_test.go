@@ -0,0 +1,101 @@
+package kram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+func TestSampleStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []Resources
+		want    UsageStats
+	}{
+		{
+			name: "no samples",
+			want: UsageStats{},
+		},
+		{
+			name:    "single sample",
+			samples: []Resources{{CPU: 10, Memory: 100}},
+			want: UsageStats{
+				Min: Resources{CPU: 10, Memory: 100}, Avg: Resources{CPU: 10, Memory: 100}, P50: Resources{CPU: 10, Memory: 100},
+				P95: Resources{CPU: 10, Memory: 100}, Max: Resources{CPU: 10, Memory: 100},
+			},
+		},
+		{
+			name:    "cpu and memory are sorted independently",
+			samples: []Resources{{CPU: 30, Memory: 100}, {CPU: 10, Memory: 300}, {CPU: 20, Memory: 200}},
+			want: UsageStats{
+				Min: Resources{CPU: 10, Memory: 100}, Avg: Resources{CPU: 20, Memory: 200}, P50: Resources{CPU: 20, Memory: 200},
+				P95: Resources{CPU: 30, Memory: 300}, Max: Resources{CPU: 30, Memory: 300},
+			},
+		},
+		{
+			name: "nearest rank percentiles",
+			samples: []Resources{
+				{CPU: 1}, {CPU: 2}, {CPU: 3}, {CPU: 4}, {CPU: 5}, {CPU: 6}, {CPU: 7}, {CPU: 8}, {CPU: 9}, {CPU: 10},
+				{CPU: 11}, {CPU: 12}, {CPU: 13}, {CPU: 14}, {CPU: 15}, {CPU: 16}, {CPU: 17}, {CPU: 18}, {CPU: 19}, {CPU: 100},
+			},
+			want: UsageStats{Min: Resources{CPU: 1}, Avg: Resources{CPU: 14}, P50: Resources{CPU: 10}, P95: Resources{CPU: 19}, Max: Resources{CPU: 100}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SampleStats(tt.samples); got != tt.want {
+				t.Errorf("SampleStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSampleCancelled(t *testing.T) {
+	client := kubefake.NewClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "web"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "api"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	})
+	// Each reading uses 10m more CPU than the previous one
+	metrics := metricsfake.NewSimpleClientset()
+	readings := 0
+	metrics.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		readings++
+		return true, &metricsv1beta1.PodMetricsList{Items: []metricsv1beta1.PodMetrics{{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "web"},
+			Containers: []metricsv1beta1.ContainerMetrics{{Name: "api", Usage: resourceList(fmt.Sprintf("%dm", readings*10), "64Mi")}},
+		}}}, nil
+	})
+
+	// Cancelled after the first tick: two readings out of 100
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := NewCollector(client, metrics)
+	progress := 0
+	c.Progress = func() {
+		if progress++; progress == 2 {
+			cancel()
+		}
+	}
+	cluster, errs := c.Sample(ctx, []string{"web"}, time.Second, 10*time.Millisecond)
+
+	if len(errs) != 1 || !errors.Is(errs[0], context.Canceled) {
+		t.Fatalf("Sample() errors = %v, want context canceled", errs)
+	}
+	container := cluster.Namespace("web").Pods[0].Containers[0]
+	want := Resources{CPU: 15, Memory: 64 << 20}
+	if cluster.Samples != 2 || len(container.Samples) != 2 || container.Usage != want {
+		t.Errorf("Sample() = %d samples, %d readings, usage %+v, want 2, 2, %+v", cluster.Samples, len(container.Samples), container.Usage, want)
+	}
+}
@@ -0,0 +1,205 @@
+package kram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+// ============================================================
+// SNAPSHOT — offline record and replay
+// ============================================================
+
+// SnapshotAPIVersion is bumped whenever the snapshot file layout changes incompatibly
+const SnapshotAPIVersion = "kram.snapshot/v1"
+
+// Snapshot holds the raw API objects the Collector reads, so a collection can be replayed offline
+type Snapshot struct {
+	APIVersion string                      `json:"apiVersion"`
+	CapturedAt time.Time                   `json:"capturedAt"`
+	Namespaces []corev1.Namespace          `json:"namespaces"`
+	Pods       []corev1.Pod                `json:"pods"`
+	PodMetrics []metricsv1beta1.PodMetrics `json:"podMetrics"`
+	// Nodes and NodeMetrics are optional: snapshots taken without node access replay without capacity
+	Nodes       []corev1.Node                `json:"nodes,omitempty"`
+	NodeMetrics []metricsv1beta1.NodeMetrics `json:"nodeMetrics,omitempty"`
+	// ReplicaSets and Jobs are optional: without them workloads are resolved from the pod-template-hash label
+	ReplicaSets []appsv1.ReplicaSet `json:"replicaSets,omitempty"`
+	Jobs        []batchv1.Job       `json:"jobs,omitempty"`
+}
+
+// Snapshot captures every namespace, pod and pod metrics of the cluster, its nodes when IncludeNodes is set
+// and its ReplicaSets and Jobs when ResolveOwners is set
+func (c *Collector) Snapshot(ctx context.Context) (*Snapshot, error) {
+	snap := &Snapshot{APIVersion: SnapshotAPIVersion, CapturedAt: time.Now().UTC()}
+
+	var namespaces *corev1.NamespaceList
+	var pods *corev1.PodList
+	var podMetrics *metricsv1beta1.PodMetricsList
+	err := c.call(func() error {
+		var e error
+		if namespaces, e = c.client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{}); e != nil {
+			return e
+		}
+		if pods, e = c.client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{}); e != nil {
+			return e
+		}
+		podMetrics, e = c.metrics.MetricsV1beta1().PodMetricses(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+		return e
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	snap.Namespaces = namespaces.Items
+	snap.Pods = pods.Items
+	snap.PodMetrics = podMetrics.Items
+
+	if c.IncludeNodes {
+		var nodes *corev1.NodeList
+		var nodeMetrics *metricsv1beta1.NodeMetricsList
+		err := c.call(func() error {
+			var e error
+			if nodes, e = c.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{}); e != nil {
+				return e
+			}
+			nodeMetrics, e = c.metrics.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{})
+			return e
+		})
+		if err != nil {
+			return nil, err
+		}
+		snap.Nodes = nodes.Items
+		snap.NodeMetrics = nodeMetrics.Items
+	}
+
+	if c.ResolveOwners {
+		var replicaSets *appsv1.ReplicaSetList
+		var jobs *batchv1.JobList
+		err := c.call(func() error {
+			var e error
+			if replicaSets, e = c.client.AppsV1().ReplicaSets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{}); e != nil {
+				return e
+			}
+			jobs, e = c.client.BatchV1().Jobs(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+			return e
+		})
+		if err != nil {
+			return nil, err
+		}
+		snap.ReplicaSets = replicaSets.Items
+		snap.Jobs = jobs.Items
+	}
+
+	// Managed fields are only noise for replay and make up a large part of the file
+	for i := range snap.Namespaces {
+		snap.Namespaces[i].ManagedFields = nil
+	}
+	for i := range snap.Pods {
+		snap.Pods[i].ManagedFields = nil
+	}
+	for i := range snap.PodMetrics {
+		snap.PodMetrics[i].ManagedFields = nil
+	}
+	for i := range snap.Nodes {
+		snap.Nodes[i].ManagedFields = nil
+	}
+	for i := range snap.NodeMetrics {
+		snap.NodeMetrics[i].ManagedFields = nil
+	}
+	for i := range snap.ReplicaSets {
+		snap.ReplicaSets[i].ManagedFields = nil
+	}
+	for i := range snap.Jobs {
+		snap.Jobs[i].ManagedFields = nil
+	}
+
+	return snap, nil
+}
+
+// ReadSnapshot loads a snapshot written by WriteFile
+func ReadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	snap := &Snapshot{}
+	if err := json.Unmarshal(data, snap); err != nil {
+		return nil, fmt.Errorf("cannot decode snapshot %s: %w", path, err)
+	}
+	if snap.APIVersion != SnapshotAPIVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %q in %s, expected %q", snap.APIVersion, path, SnapshotAPIVersion)
+	}
+	return snap, nil
+}
+
+// WriteFile saves the snapshot as indented JSON
+func (s *Snapshot) WriteFile(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Clients returns in-memory clientsets serving the snapshot objects, to be passed to NewCollector
+func (s *Snapshot) Clients() (kubernetes.Interface, metricsv.Interface, error) {
+	client := kubefake.NewClientset()
+	for i := range s.Namespaces {
+		if err := client.Tracker().Add(&s.Namespaces[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	for i := range s.Pods {
+		if err := client.Tracker().Add(&s.Pods[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	for i := range s.Nodes {
+		if err := client.Tracker().Add(&s.Nodes[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	for i := range s.ReplicaSets {
+		if err := client.Tracker().Add(&s.ReplicaSets[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	for i := range s.Jobs {
+		if err := client.Tracker().Add(&s.Jobs[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// The generated fake serves PodMetricses and NodeMetricses from the "pods" and "nodes"
+	// resources while Tracker().Add would guess "podmetricses", so objects are registered explicitly.
+	metrics := metricsfake.NewSimpleClientset()
+	podMetricsResource := metricsv1beta1.SchemeGroupVersion.WithResource("pods")
+	for i := range s.PodMetrics {
+		pm := &s.PodMetrics[i]
+		if err := metrics.Tracker().Create(podMetricsResource, pm, pm.Namespace); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	nodeMetricsResource := metricsv1beta1.SchemeGroupVersion.WithResource("nodes")
+	for i := range s.NodeMetrics {
+		if err := metrics.Tracker().Create(nodeMetricsResource, &s.NodeMetrics[i], ""); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return client, metrics, nil
+}
@@ -0,0 +1,142 @@
+package kram
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+func resourceList(cpu, memory string) corev1.ResourceList {
+	return corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(memory)}
+}
+
+func TestSnapshotReplay(t *testing.T) {
+	const mi = 1 << 20
+	snap := &Snapshot{
+		APIVersion: SnapshotAPIVersion,
+		Namespaces: []corev1.Namespace{
+			{ObjectMeta: metav1.ObjectMeta{Name: "web"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "idle"}},
+		},
+		Pods: []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "web"},
+				Spec: corev1.PodSpec{NodeName: "node-1", InitContainers: []corev1.Container{
+					{Name: "migrate", Resources: corev1.ResourceRequirements{Requests: resourceList("500m", "64Mi")}},
+				}, Containers: []corev1.Container{
+					{Name: "api", Resources: corev1.ResourceRequirements{Requests: resourceList("100m", "128Mi"), Limits: resourceList("200m", "256Mi")}},
+					{Name: "proxy", Resources: corev1.ResourceRequirements{Requests: resourceList("50m", "32Mi")}},
+				}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "api-2", Namespace: "web"},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{
+					{Name: "api", Resources: corev1.ResourceRequirements{Requests: resourceList("100m", "128Mi")}},
+				}},
+			},
+		},
+		PodMetrics: []metricsv1beta1.PodMetrics{{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "web"},
+			Containers: []metricsv1beta1.ContainerMetrics{
+				{Name: "api", Usage: resourceList("12m", "64Mi")},
+				{Name: "proxy", Usage: resourceList("5m", "20Mi")},
+			},
+		}},
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := snap.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, metrics, err := read.Clients()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewCollector(client, metrics)
+	ctx := context.Background()
+	namespaces, err := c.NamespaceNames(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster, errs := c.Collect(ctx, namespaces)
+	if len(errs) > 0 {
+		t.Fatalf("Collect() errors: %v", errs)
+	}
+	if len(cluster.Namespaces) != 2 || cluster.Namespace("idle") == nil || len(cluster.Namespace("idle").Pods) != 0 {
+		t.Fatalf("Collect() namespaces = %+v, want idle without pods and web", cluster.Namespaces)
+	}
+
+	tests := []struct {
+		pod        string
+		hasMetrics bool
+		kinds      []ContainerKind
+		total      Quantities
+	}{
+		{
+			pod: "api-1", hasMetrics: true, kinds: []ContainerKind{ContainerKindInit, ContainerKindApp, ContainerKindApp},
+			total: Quantities{
+				Usage: Resources{CPU: 17, Memory: 84 * mi}, Request: Resources{CPU: 500, Memory: 160 * mi}, Limit: Resources{CPU: 200, Memory: 256 * mi},
+				Set: Specified{CPURequest: true, CPULimit: true, MemoryRequest: true, MemoryLimit: true},
+			},
+		},
+		// Without metrics the pod still holds its requests
+		{
+			pod: "api-2", kinds: []ContainerKind{ContainerKindApp},
+			total: Quantities{Request: Resources{CPU: 100, Memory: 128 * mi}, Set: Specified{CPURequest: true, MemoryRequest: true}},
+		},
+	}
+	pods := cluster.Namespace("web").Pods
+	if len(pods) != len(tests) {
+		t.Fatalf("Collect() pods in web = %d, want %d", len(pods), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.pod, func(t *testing.T) {
+			p := pods[i]
+			var kinds []ContainerKind
+			for _, c := range p.Containers {
+				kinds = append(kinds, c.Kind)
+			}
+			if p.Name != tt.pod || p.HasMetrics != tt.hasMetrics || !reflect.DeepEqual(kinds, tt.kinds) {
+				t.Fatalf("pod = %s with metrics %v and containers %v, want %s, %v, %v", p.Name, p.HasMetrics, kinds, tt.pod, tt.hasMetrics, tt.kinds)
+			}
+			if got := p.Total(); got != tt.total {
+				t.Errorf("Total() = %+v, want %+v", got, tt.total)
+			}
+		})
+	}
+}
+
+func TestReadSnapshot(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "current version", content: `{"apiVersion": "` + SnapshotAPIVersion + `", "pods": []}`},
+		{name: "other version", content: `{"apiVersion": "kram.snapshot/v0"}`, wantErr: true},
+		{name: "not JSON", content: `apiVersion: kram.snapshot/v1`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "snapshot.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ReadSnapshot(path); (err != nil) != tt.wantErr {
+				t.Errorf("ReadSnapshot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
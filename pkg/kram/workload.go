@@ -0,0 +1,162 @@
+package kram
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ============================================================
+// WORKLOAD — pods aggregated by top-level controller
+// ============================================================
+
+// ownerKey identifies an intermediate controller of a namespace (ReplicaSet or Job)
+type ownerKey struct {
+	kind string
+	name string
+}
+
+// getNamespaceOwnersMap lists the ReplicaSets and Jobs owning the pods, only when some pod has one,
+// and maps each of them to its own controller. A failed list is reported in errorsList.
+func (c *Collector) getNamespaceOwnersMap(ctx context.Context, namespace string, pods []corev1.Pod, errorsList *[]error, mu *sync.Mutex) map[ownerKey]Workload {
+	var hasReplicaSets, hasJobs bool
+	for i := range pods {
+		if owner := metav1.GetControllerOf(&pods[i]); owner != nil {
+			hasReplicaSets = hasReplicaSets || owner.Kind == "ReplicaSet"
+			hasJobs = hasJobs || owner.Kind == "Job"
+		}
+	}
+
+	result := make(map[ownerKey]Workload)
+	addOwner := func(kind string, meta metav1.Object) {
+		if parent := metav1.GetControllerOfNoCopy(meta); parent != nil {
+			result[ownerKey{kind, meta.GetName()}] = Workload{Kind: parent.Kind, Name: parent.Name}
+		}
+	}
+	report := func(err error) {
+		mu.Lock()
+		*errorsList = append(*errorsList, err)
+		mu.Unlock()
+	}
+
+	if hasReplicaSets {
+		var replicaSets *appsv1.ReplicaSetList
+		err := c.call(func() error {
+			var e error
+			replicaSets, e = c.client.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+			return e
+		})
+		if err != nil {
+			report(err)
+		} else {
+			for i := range replicaSets.Items {
+				addOwner("ReplicaSet", &replicaSets.Items[i])
+			}
+		}
+	}
+
+	if hasJobs {
+		var jobs *batchv1.JobList
+		err := c.call(func() error {
+			var e error
+			jobs, e = c.client.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+			return e
+		})
+		if err != nil {
+			report(err)
+		} else {
+			for i := range jobs.Items {
+				addOwner("Job", &jobs.Items[i])
+			}
+		}
+	}
+
+	return result
+}
+
+// podWorkload returns the top-level controller of the pod: the controller of its ReplicaSet or Job
+// when owners knows it, otherwise its own controller. Without owners, ReplicaSets created by a
+// Deployment are recognised by the pod-template-hash suffix, so no extra API call is needed.
+func podWorkload(pod *corev1.Pod, owners map[ownerKey]Workload) Workload {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return Workload{Kind: "Pod", Name: pod.Name}
+	}
+	if parent, ok := owners[ownerKey{owner.Kind, owner.Name}]; ok {
+		return parent
+	}
+	if hash, ok := pod.Labels["pod-template-hash"]; ok && owner.Kind == "ReplicaSet" && strings.HasSuffix(owner.Name, "-"+hash) {
+		return Workload{Kind: "Deployment", Name: strings.TrimSuffix(owner.Name, "-"+hash)}
+	}
+	return Workload{Kind: owner.Kind, Name: owner.Name}
+}
+
+// WorkloadPods is the set of replicas of one workload
+type WorkloadPods struct {
+	Namespace string
+	Workload  Workload
+	Pods      []*Pod
+}
+
+// Total sums the quantities of all replicas
+func (w *WorkloadPods) Total() Quantities {
+	var total Quantities
+	for _, p := range w.Pods {
+		total.Add(p.Total())
+	}
+	return total
+}
+
+// PerReplica averages the quantities of the replicas reported by metrics-server;
+// pending or completed replicas would otherwise lower the average
+func (w *WorkloadPods) PerReplica() Quantities {
+	var total Quantities
+	var n int64
+	for _, p := range w.Pods {
+		if p.HasMetrics {
+			total.Add(p.Total())
+			n++
+		}
+	}
+	if n == 0 {
+		return Quantities{}
+	}
+	return Quantities{
+		Usage:   Resources{CPU: total.Usage.CPU / n, Memory: total.Usage.Memory / n},
+		Request: Resources{CPU: total.Request.CPU / n, Memory: total.Request.Memory / n},
+		Limit:   Resources{CPU: total.Limit.CPU / n, Memory: total.Limit.Memory / n},
+		Set:     total.Set,
+	}
+}
+
+// Workloads groups the pods of the namespace by workload, sorted by kind/name
+func (ns *Namespace) Workloads() []*WorkloadPods {
+	byWorkload := make(map[Workload]*WorkloadPods)
+	var workloads []*WorkloadPods
+	for _, p := range ns.Pods {
+		w, ok := byWorkload[p.Workload]
+		if !ok {
+			w = &WorkloadPods{Namespace: ns.Name, Workload: p.Workload}
+			byWorkload[p.Workload] = w
+			workloads = append(workloads, w)
+		}
+		w.Pods = append(w.Pods, p)
+	}
+	sort.Slice(workloads, func(i, j int) bool { return workloads[i].Workload.String() < workloads[j].Workload.String() })
+	return workloads
+}
+
+// Workloads lists the workloads of every namespace, in namespace order
+func (c *Cluster) Workloads() []*WorkloadPods {
+	var workloads []*WorkloadPods
+	for _, ns := range c.Namespaces {
+		workloads = append(workloads, ns.Workloads()...)
+	}
+	return workloads
+}
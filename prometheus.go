@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PaulPowershell/Kram/pkg/kram"
+	"github.com/pterm/pterm"
+)
+
+// ============================================================
+// PROMETHEUS — /metrics de kram serve (format texte 0.0.4)
+// ============================================================
+
+type promLabel struct {
+	name  string
+	value string
+}
+
+type promSample struct {
+	labels []promLabel
+	value  float64
+}
+
+type promFamily struct {
+	help    string
+	samples []promSample
+}
+
+// promRegistry groups samples by metric family, as the exposition format requires
+type promRegistry struct {
+	families map[string]*promFamily
+}
+
+func newPromRegistry() *promRegistry {
+	return &promRegistry{families: make(map[string]*promFamily)}
+}
+
+// add records one gauge sample
+func (p *promRegistry) add(name string, help string, value float64, labels ...promLabel) {
+	family, ok := p.families[name]
+	if !ok {
+		family = &promFamily{help: help}
+		p.families[name] = family
+	}
+	family.samples = append(family.samples, promSample{labels: labels, value: value})
+}
+
+// addQuantities records the usage, request and limit gauges of scope (e.g. kram_namespace_cpu_usage_millicores)
+func (p *promRegistry) addQuantities(scope string, what string, q kram.Quantities, labels ...promLabel) {
+	p.add("kram_"+scope+"_cpu_usage_millicores", "CPU usage of "+what+" in millicores.", float64(q.Usage.CPU), labels...)
+	p.add("kram_"+scope+"_cpu_request_millicores", "CPU requests of "+what+" in millicores.", float64(q.Request.CPU), labels...)
+	p.add("kram_"+scope+"_cpu_limit_millicores", "CPU limits of "+what+" in millicores.", float64(q.Limit.CPU), labels...)
+	p.add("kram_"+scope+"_memory_usage_bytes", "Memory usage of "+what+" in bytes.", float64(q.Usage.Memory), labels...)
+	p.add("kram_"+scope+"_memory_request_bytes", "Memory requests of "+what+" in bytes.", float64(q.Request.Memory), labels...)
+	p.add("kram_"+scope+"_memory_limit_bytes", "Memory limits of "+what+" in bytes.", float64(q.Limit.Memory), labels...)
+}
+
+// write prints the families sorted by name in the Prometheus text exposition format
+func (p *promRegistry) write(w io.Writer) error {
+	names := make([]string, 0, len(p.families))
+	for name := range p.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		family := p.families[name]
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s gauge\n", name, family.help, name)
+		for _, sample := range family.samples {
+			sb.WriteString(name)
+			if len(sample.labels) > 0 {
+				pairs := make([]string, len(sample.labels))
+				for i, l := range sample.labels {
+					pairs[i] = l.name + `="` + promEscape(l.value) + `"`
+				}
+				sb.WriteString("{" + strings.Join(pairs, ",") + "}")
+			}
+			sb.WriteString(" " + strconv.FormatFloat(sample.value, 'g', -1, 64) + "\n")
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// promEscape escapes a label value
+func promEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// clusterMetrics exposes the namespaces, the namespace×node matrix, the containers and the nodes of the cluster
+func clusterMetrics(cluster *kram.Cluster, errorsList []error) *promRegistry {
+	p := newPromRegistry()
+
+	for _, ns := range cluster.Namespaces {
+		if len(ns.Pods) == 0 {
+			continue
+		}
+		nsLabel := promLabel{"namespace", ns.Name}
+		counts := kram.CountPods(ns.Pods)
+		for _, phase := range []struct {
+			name  string
+			count int
+		}{{"running", counts.Running}, {"pending", counts.Pending}, {"completed", counts.Completed}} {
+			p.add("kram_namespace_pods", "Number of pods of the namespace per phase (running, pending, completed).", float64(phase.count), nsLabel, promLabel{"phase", phase.name})
+		}
+		p.addQuantities("namespace", "the namespace", ns.Total(), nsLabel)
+
+		for _, n := range ns.ByNode() {
+			p.addQuantities("namespace_node", "the namespace pods on the node", n.Quantities, nsLabel, promLabel{"node", n.Name})
+		}
+
+		for _, pod := range ns.Pods {
+			for _, container := range pod.Containers {
+				p.addQuantities("container", "the container", container.Quantities,
+					nsLabel, promLabel{"node", pod.NodeName}, promLabel{"pod", pod.Name}, promLabel{"container", container.Name}, promLabel{"kind", string(container.Kind)})
+			}
+		}
+	}
+
+	for _, node := range cluster.Nodes {
+		nodeLabel := promLabel{"node", node.Name}
+		p.add("kram_node_cpu_capacity_millicores", "CPU capacity of the node in millicores.", float64(node.Capacity.CPU), nodeLabel)
+		p.add("kram_node_cpu_allocatable_millicores", "Allocatable CPU of the node in millicores.", float64(node.Allocatable.CPU), nodeLabel)
+		p.add("kram_node_memory_capacity_bytes", "Memory capacity of the node in bytes.", float64(node.Capacity.Memory), nodeLabel)
+		p.add("kram_node_memory_allocatable_bytes", "Allocatable memory of the node in bytes.", float64(node.Allocatable.Memory), nodeLabel)
+		if node.HasMetrics {
+			p.add("kram_node_cpu_usage_millicores", "CPU usage of the whole node in millicores.", float64(node.Usage.CPU), nodeLabel)
+			p.add("kram_node_memory_usage_bytes", "Memory usage of the whole node in bytes.", float64(node.Usage.Memory), nodeLabel)
+		}
+	}
+
+	p.add("kram_collect_errors", "Number of errors of the last collection.", float64(len(errorsList)))
+	if !cluster.CollectedAt.IsZero() {
+		p.add("kram_collect_timestamp_seconds", "Unix time of the last collection.", float64(cluster.CollectedAt.Unix()))
+	}
+	return p
+}
+
+// handleMetrics serves /metrics from the background collection, or collects at each scrape without --refresh
+func (s *server) handleMetrics(w http.ResponseWriter, req *http.Request) {
+	viewCfg := *s.cfg
+	viewCfg.Namespace = ""
+	viewCfg.ShowNode = true
+
+	cluster, errorsList := s.collect(req.Context(), &viewCfg)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := clusterMetrics(cluster, errorsList).write(w); err != nil {
+		pterm.Error.Println("Cannot write metrics response:", err)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/PaulPowershell/Kram/pkg/kram"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// ============================================================
+// RECOMMEND (kram recommend [namespace])
+// ============================================================
+
+func newRecommendCmd(cfg *Config) *cobra.Command {
+	var headroom, limitRatio float64
+	var patch bool
+
+	recommendCmd := &cobra.Command{
+		Use:   "recommend [namespace]",
+		Short: "Propose container requests and limits from observed usage",
+		Long: "Recommend aggregates the containers of every workload, proposes requests from the peak usage of its replicas plus headroom, " +
+			"and flags containers whose current request is over- or under-provisioned.",
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			// Replicas are aggregated per top-level controller, and CronJobs are patched rather than their Jobs
+			cfg.ResolveOwners = true
+			cluster, errorsList := collectForCommand(cfg, args, patch, func() error {
+				if headroom < 0 || limitRatio < 0 || (limitRatio > 0 && limitRatio < 1) {
+					return ErrInvalidRecommendOptions
+				}
+				return nil
+			})
+			recs := kram.Recommend(cluster, kram.RecommendOptions{Headroom: headroom / 100, LimitRatio: limitRatio})
+
+			if patch {
+				if err := writeRecommendationPatches(recs); err != nil {
+					pterm.Error.Println(err)
+					os.Exit(1)
+				}
+			} else {
+				renderReport(cfg.OutputFormat, recommendationsReport(recs, cfg.Namespace), errorsList)
+			}
+
+			if patch || !renderers[cfg.OutputFormat].embedsErrors {
+				printErrors(errorsList)
+			}
+		},
+	}
+
+	addCollectionFlags(recommendCmd, cfg, "Recommend for every namespace")
+	addSamplingFlags(recommendCmd, cfg, "Sample usage over this window and recommend from the p95 of each replica (e.g. 1h)")
+	recommendCmd.Flags().Float64Var(&headroom, "headroom", 20, "Percentage added on top of the peak usage for the recommended requests")
+	recommendCmd.Flags().Float64Var(&limitRatio, "limit-ratio", 2, "Recommended limits as a multiple of the recommended requests (0 recommends no limits)")
+	recommendCmd.Flags().BoolVar(&patch, "patch", false, "Print the flagged containers as strategic merge patches instead of a report")
+
+	return recommendCmd
+}
+
+func recommendationsReport(recs []kram.Recommendation, namespace string) *report {
+	header := []string{"Namespace", "Workload", "Container", "Pods", "Usage", "Request", "Limit", "Rec. Request", "Rec. Limit", "Status"}
+	cpuTableData := [][]string{header}
+	memTableData := [][]string{header}
+
+	doc := newJSONDocument("recommend", namespace)
+	var over, under int
+	for _, rec := range recs {
+		// --limit-ratio 0 recommends no limit
+		limitSet := rec.Limit != (kram.Resources{})
+		prefix := []string{rec.Namespace, rec.Workload.String(), rec.Container, pterm.Sprint(rec.Pods)}
+		cpuTableData = append(cpuTableData, append(prefix,
+			formatCPU(rec.Current.Usage.CPU), orUnset(rec.Current.Set.CPURequest, formatCPU(rec.Current.Request.CPU)), orUnset(rec.Current.Set.CPULimit, formatCPU(rec.Current.Limit.CPU)),
+			formatCPU(rec.Request.CPU), orUnset(limitSet, formatCPU(rec.Limit.CPU)), string(rec.CPU)))
+		memTableData = append(memTableData, append(prefix,
+			formatMemory(rec.Current.Usage.Memory), orUnset(rec.Current.Set.MemoryRequest, formatMemory(rec.Current.Request.Memory)), orUnset(rec.Current.Set.MemoryLimit, formatMemory(rec.Current.Limit.Memory)),
+			formatMemory(rec.Request.Memory), orUnset(limitSet, formatMemory(rec.Limit.Memory)), string(rec.Memory)))
+		doc.Recommendations = append(doc.Recommendations, newJSONRecommendation(rec))
+
+		switch {
+		case rec.CPU == kram.UnderProvisioned || rec.Memory == kram.UnderProvisioned:
+			under++
+		case rec.CPU == kram.OverProvisioned || rec.Memory == kram.OverProvisioned:
+			over++
+		}
+	}
+
+	summary := [][]string{
+		{"Containers", "Under-provisioned", "Over-provisioned"},
+		{pterm.Sprint(len(recs)), pterm.Sprint(under), pterm.Sprint(over)},
+	}
+
+	name := "kram-recommend"
+	if namespace != "" {
+		name = fmt.Sprintf("kram-recommend-%s", namespace)
+	}
+	return &report{
+		Name: name,
+		Sections: []reportSection{
+			{Title: "Recommendations", Data: summary},
+			{Title: "CPU — Current / Recommended", Data: cpuTableData},
+			{Title: "Memory — Current / Recommended", Data: memTableData},
+		},
+		Document: doc,
+	}
+}
+
+// patchAPIVersions lists the workload kinds whose pod template can be patched
+var patchAPIVersions = map[string]string{
+	"Deployment":  "apps/v1",
+	"StatefulSet": "apps/v1",
+	"DaemonSet":   "apps/v1",
+	"ReplicaSet":  "apps/v1",
+	"Job":         "batch/v1",
+	"CronJob":     "batch/v1",
+}
+
+// writeRecommendationPatches prints one strategic merge patch per workload with flagged containers,
+// usable with kubectl patch --patch-file or as a kustomize patch. Sidecars are patched under initContainers.
+func writeRecommendationPatches(recs []kram.Recommendation) error {
+	type patchTarget struct {
+		namespace string
+		workload  kram.Workload
+	}
+	var targets []patchTarget
+	containers := make(map[patchTarget][]map[string]any)
+	initContainers := make(map[patchTarget][]map[string]any)
+
+	for _, rec := range recs {
+		if rec.CPU == kram.ProvisioningOK && rec.Memory == kram.ProvisioningOK {
+			continue
+		}
+		t := patchTarget{rec.Namespace, rec.Workload}
+		_, seen := containers[t]
+		if _, ok := initContainers[t]; !ok && !seen {
+			targets = append(targets, t)
+		}
+		resources := map[string]any{
+			"requests": map[string]string{
+				"cpu":    resource.NewMilliQuantity(rec.Request.CPU, resource.DecimalSI).String(),
+				"memory": resource.NewQuantity(rec.Request.Memory, resource.BinarySI).String(),
+			},
+		}
+		if rec.Limit != (kram.Resources{}) {
+			resources["limits"] = map[string]string{
+				"cpu":    resource.NewMilliQuantity(rec.Limit.CPU, resource.DecimalSI).String(),
+				"memory": resource.NewQuantity(rec.Limit.Memory, resource.BinarySI).String(),
+			}
+		}
+		entry := map[string]any{"name": rec.Container, "resources": resources}
+		if rec.Kind == kram.ContainerKindSidecar {
+			initContainers[t] = append(initContainers[t], entry)
+		} else {
+			containers[t] = append(containers[t], entry)
+		}
+	}
+
+	for i, t := range targets {
+		if i > 0 {
+			fmt.Println("---")
+		}
+		apiVersion, ok := patchAPIVersions[t.workload.Kind]
+		if !ok {
+			fmt.Printf("# %s -n %s: not patchable, update the manifest it comes from\n", t.workload, t.namespace)
+			continue
+		}
+		podSpec := make(map[string]any)
+		if len(initContainers[t]) > 0 {
+			podSpec["initContainers"] = initContainers[t]
+		}
+		if len(containers[t]) > 0 {
+			podSpec["containers"] = containers[t]
+		}
+		spec := map[string]any{
+			"template": map[string]any{"spec": podSpec},
+		}
+		// The pod template of a CronJob is nested in its job template
+		if t.workload.Kind == "CronJob" {
+			spec = map[string]any{"jobTemplate": map[string]any{"spec": spec}}
+		}
+		data, err := yaml.Marshal(map[string]any{
+			"apiVersion": apiVersion,
+			"kind":       t.workload.Kind,
+			"metadata":   map[string]string{"name": t.workload.Name, "namespace": t.namespace},
+			"spec":       spec,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("# %s -n %s\n%s", t.workload, t.namespace, data)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PaulPowershell/Kram/pkg/kram"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/pterm/pterm"
+	"sigs.k8s.io/yaml"
+)
+
+// ============================================================
+// TYPES
+// ============================================================
+
+// report is everything a view produces; each renderer picks the parts it needs
+type report struct {
+	Name     string // base file name, e.g. "kram-namespaces"
+	Sections []reportSection
+	Charts   []*charts.Bar
+	Document jsonDocument
+	Errors   []error
+}
+
+type reportSection struct {
+	Title string
+	Data  [][]string
+	// Detail sections are collapsed in the HTML page
+	Detail bool
+}
+
+// renderer turns a report into one output format
+type renderer struct {
+	render func(r *report) error
+	// machineReadable renderers own stdout: spinners, progress bars and warnings go to stderr
+	machineReadable bool
+	// embedsErrors renderers write the collected errors into the document itself
+	embedsErrors bool
+}
+
+// ============================================================
+// REGISTRY
+// ============================================================
+
+var renderers = map[string]renderer{
+	"table": {render: renderTable},
+	"html":  {render: renderHTMLReport},
+	"json":  {render: renderJSON, machineReadable: true, embedsErrors: true},
+	"yaml":  {render: renderYAML, machineReadable: true, embedsErrors: true},
+	"csv":   {render: renderCSV, machineReadable: true},
+}
+
+// outputFormats returns the registered output formats in alphabetical order
+func outputFormats() []string {
+	formats := make([]string, 0, len(renderers))
+	for name := range renderers {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// renderReport renders r with the renderer registered for format
+func renderReport(format string, r *report, errorsList []error) {
+	rd, ok := renderers[format]
+	if !ok {
+		pterm.Error.Println(invalidOutputError())
+		os.Exit(1)
+	}
+	r.Errors = errorsList
+	if err := rd.render(r); err != nil {
+		pterm.Error.Printf("Cannot render %s output: %v\n", format, err)
+		os.Exit(1)
+	}
+}
+
+// invalidOutputError lists the registered formats in the error message
+func invalidOutputError() error {
+	return fmt.Errorf("%w. Use one of: %s", ErrInvalidOutput, strings.Join(outputFormats(), ", "))
+}
+
+// ============================================================
+// TABLE RENDERER
+// ============================================================
+
+func renderTable(r *report) error {
+	s, err := sprintSections(r.Sections)
+	if err != nil {
+		return err
+	}
+	pterm.Print(s)
+	return nil
+}
+
+// sprintSections renders the sections as titled boxed tables, also used to redraw the watch dashboard
+func sprintSections(sections []reportSection) (string, error) {
+	var sb strings.Builder
+	for i, section := range sections {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		if section.Title != "" {
+			sb.WriteString(section.Title + "\n")
+		}
+		table, err := pterm.DefaultTable.WithHeaderRowSeparator("─").WithBoxed().WithHasHeader().WithAlternateRowStyle(alternateStyle).WithData(section.Data).Srender()
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(table + "\n")
+	}
+	return sb.String(), nil
+}
+
+// ============================================================
+// YAML RENDERER
+// ============================================================
+
+func renderYAML(r *report) error {
+	out, err := yaml.Marshal(r.document())
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
+
+// ============================================================
+// CSV RENDERER
+// ============================================================
+
+// renderCSV writes the most detailed records of the document as a single flat table
+func renderCSV(r *report) error {
+	doc := r.document()
+	resourceHeader := []string{"cpu_usage_millicores", "cpu_request_millicores", "cpu_limit_millicores", "memory_usage_bytes", "memory_request_bytes", "memory_limit_bytes"}
+
+	var rows [][]string
+	switch {
+	case doc.View == "recommend":
+		rows = append(rows, append(append([]string{"namespace", "workload_kind", "workload", "container", "container_kind", "pods"}, resourceHeader...),
+			"recommended_cpu_request_millicores", "recommended_cpu_limit_millicores", "recommended_memory_request_bytes", "recommended_memory_limit_bytes", "cpu_status", "memory_status"))
+		for _, rec := range doc.Recommendations {
+			row := append([]string{rec.Namespace, rec.WorkloadKind, rec.Workload, rec.Container, rec.Kind, strconv.Itoa(rec.Pods)}, csvResources(rec.jsonResources)...)
+			rows = append(rows, append(row,
+				strconv.FormatInt(rec.Recommended.CPURequest, 10),
+				csvSet(rec.Recommended.CPULimit),
+				strconv.FormatInt(rec.Recommended.MemoryRequest, 10),
+				csvSet(rec.Recommended.MemoryLimit),
+				rec.CPUStatus, rec.MemoryStatus))
+		}
+	case doc.View == "check":
+		rows = append(rows, []string{"severity", "rule", "scope", "namespace", "name", "message"})
+		for _, v := range doc.Violations {
+			rows = append(rows, []string{v.Severity, v.Rule, v.Scope, v.Namespace, v.Name, v.Message})
+		}
+	case doc.View == "missing":
+		rows = append(rows, []string{"namespace", "workload_kind", "workload", "pod", "container", "container_kind", "qos_class", "cpu_request_unset", "cpu_limit_unset", "memory_request_unset", "memory_limit_unset"})
+		for _, m := range doc.Missing {
+			row := []string{m.Namespace, m.WorkloadKind, m.Workload, m.Pod, m.Container, m.Kind, m.QOSClass}
+			for _, name := range []string{kram.CPURequest, kram.CPULimit, kram.MemoryRequest, kram.MemoryLimit} {
+				row = append(row, strconv.FormatBool(slices.Contains(m.Unset, name)))
+			}
+			rows = append(rows, row)
+		}
+	case doc.View == "cost" && doc.Cost != nil:
+		rows = csvCostRows(doc.Cost)
+	case doc.View == "waste":
+		rows = append(rows, append(append([]string{"namespace", "name"}, resourceHeader...), "cpu_wasted_millicores", "memory_wasted_bytes"))
+		for _, w := range doc.Wasters {
+			row := append([]string{w.Namespace, w.Name}, csvResources(w.jsonResources)...)
+			rows = append(rows, append(row, strconv.FormatInt(w.Efficiency.WastedCPU, 10), strconv.FormatInt(w.Efficiency.WastedMemory, 10)))
+		}
+	case doc.View == "workloads":
+		rows = append(rows, append([]string{"namespace", "workload_kind", "workload", "replicas"}, resourceHeader...))
+		for _, w := range doc.Workloads {
+			rows = append(rows, append([]string{w.Namespace, w.Kind, w.Name, strconv.Itoa(w.Replicas)}, csvResources(w.jsonResources)...))
+		}
+	case len(doc.Pods) > 0:
+		rows = append(rows, append([]string{"namespace", "pod", "node", "phase", "container", "container_kind"}, resourceHeader...))
+		for _, pod := range doc.Pods {
+			if len(pod.Containers) == 0 {
+				rows = append(rows, append([]string{pod.Namespace, pod.Name, pod.Node, pod.Phase, "", ""}, csvResources(pod.jsonResources)...))
+				continue
+			}
+			for _, container := range pod.Containers {
+				rows = append(rows, append([]string{pod.Namespace, pod.Name, pod.Node, pod.Phase, container.Name, container.Kind}, csvResources(container.jsonResources)...))
+			}
+		}
+	case doc.View == "clusters":
+		rows = append(rows, append([]string{"cluster", "namespace", "pods", "running", "pending", "completed"}, resourceHeader...))
+		for _, cluster := range doc.Clusters {
+			for _, ns := range cluster.Namespaces {
+				rows = append(rows, append(append([]string{cluster.Name, ns.Name, strconv.Itoa(ns.Pods)}, csvPodCounts(ns.jsonPodCounts)...), csvResources(ns.jsonResources)...))
+			}
+		}
+	case doc.View == "nodes":
+		rows = append(rows, append([]string{"namespace", "node"}, resourceHeader...))
+		for _, ns := range doc.Namespaces {
+			for _, node := range ns.Nodes {
+				rows = append(rows, append([]string{ns.Name, node.Name}, csvResources(node.jsonResources)...))
+			}
+		}
+	default:
+		rows = append(rows, append([]string{"namespace", "pods", "running", "pending", "completed"}, resourceHeader...))
+		for _, ns := range doc.Namespaces {
+			rows = append(rows, append(append([]string{ns.Name, strconv.Itoa(ns.Pods)}, csvPodCounts(ns.jsonPodCounts)...), csvResources(ns.jsonResources)...))
+		}
+	}
+
+	return csv.NewWriter(os.Stdout).WriteAll(rows)
+}
+
+// csvPodCounts formats the pods of each phase as CSV cells, empty when not counted
+func csvPodCounts(c *jsonPodCounts) []string {
+	if c == nil {
+		return []string{"", "", ""}
+	}
+	return []string{strconv.Itoa(c.Running), strconv.Itoa(c.Pending), strconv.Itoa(c.Completed)}
+}
+
+// csvResources formats raw quantities as CSV cells, unset requests and limits as empty cells
+func csvResources(res jsonResources) []string {
+	return []string{
+		strconv.FormatInt(res.CPUUsage, 10),
+		csvSet(res.CPURequest),
+		csvSet(res.CPULimit),
+		strconv.FormatInt(res.MemoryUsage, 10),
+		csvSet(res.MemoryRequest),
+		csvSet(res.MemoryLimit),
+	}
+}
+
+// csvSet formats a request or limit, an empty cell when it is not set
+func csvSet(v *int64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatInt(*v, 10)
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/PaulPowershell/Kram/pkg/kram"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// ============================================================
+// SERVE (kram serve --listen :8080)
+// ============================================================
+
+func newServeCmd(cfg *Config) *cobra.Command {
+	var listen string
+	var refresh time.Duration
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the HTML report, its JSON data and Prometheus metrics over HTTP",
+		Long: "Serve exposes every view as an HTML page (/, /ns/{name}, /nodes, /ns/{name}/nodes), as JSON under /api " +
+			"and as Prometheus gauges on /metrics. The report is collected at each request, or every --refresh in the background.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			spinner, _ := pterm.DefaultSpinner.Start("Initialization running")
+
+			if err := cfg.Validate(); err != nil {
+				spinner.Fail("Initialization error")
+				pterm.Error.Println(err)
+				os.Exit(1)
+			}
+
+			clientset, metricsClientset, err := connectClients(cfg)
+			if err != nil {
+				spinner.Fail("Initialization error")
+				pterm.Error.WithShowLineNumber(true).Println(err)
+				os.Exit(1)
+			}
+
+			spinner.Success("Initialization done")
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			s := &server{cfg: cfg, clientset: clientset, metrics: metricsClientset}
+			if refresh > 0 {
+				s.startRefresh(ctx, refresh)
+			}
+
+			httpServer := &http.Server{Addr: listen, Handler: s.routes(), ReadHeaderTimeout: 10 * time.Second}
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				_ = httpServer.Shutdown(shutdownCtx)
+			}()
+
+			pterm.Info.Printf("Serving on %s (Ctrl+C to stop)\n", listen)
+			if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				pterm.Error.Println(err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	serveCmd.Flags().StringVar(&listen, "listen", ":8080", "Address the HTTP server listens on")
+	serveCmd.Flags().DurationVar(&refresh, "refresh", 0, "Collect in the background at this interval instead of at each request (e.g. 30s)")
+	addSelectorFlags(serveCmd, cfg)
+	serveCmd.Flags().StringVar(&cfg.GroupBy, "group-by", "", "Replace namespaces (or nodes in the node views) by the values of label:<key>, annotation:<key> or node-label:<key>")
+
+	return serveCmd
+}
+
+// server builds the reports of the HTTP routes, from a cached cluster when refreshing in the background
+type server struct {
+	cfg       *Config
+	clientset kubernetes.Interface
+	metrics   metricsv.Interface
+
+	mu           sync.RWMutex
+	cached       *kram.Cluster
+	cachedErrors []error
+}
+
+func (s *server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handle(false, false))
+	mux.HandleFunc("GET /nodes", s.handle(true, false))
+	mux.HandleFunc("GET /ns/{name}", s.handle(false, false))
+	mux.HandleFunc("GET /ns/{name}/nodes", s.handle(true, false))
+	mux.HandleFunc("GET /api/namespaces", s.handle(false, true))
+	mux.HandleFunc("GET /api/nodes", s.handle(true, true))
+	mux.HandleFunc("GET /api/ns/{name}", s.handle(false, true))
+	mux.HandleFunc("GET /api/ns/{name}/nodes", s.handle(true, true))
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
+// handle serves one view; the namespace comes from the {name} path value
+func (s *server) handle(nodes bool, asJSON bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		// Each request gets its own copy of the flags, so concurrent views do not interfere
+		viewCfg := *s.cfg
+		viewCfg.Namespace = req.PathValue("name")
+		viewCfg.ShowNode = nodes
+		if err := viewCfg.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		cluster, errorsList := s.collect(req.Context(), &viewCfg)
+		view, r := buildReport(&viewCfg, cluster)
+		status := http.StatusOK
+		if r == nil {
+			if !asJSON {
+				http.Error(w, fmt.Sprintf("No pods found in namespace: %s", viewCfg.Namespace), http.StatusNotFound)
+				return
+			}
+			// Same empty document as kram -o json, with a 404 status
+			r = &report{Document: newJSONDocument(view, viewCfg.Namespace)}
+			status = http.StatusNotFound
+		}
+		r.Errors = errorsList
+
+		if asJSON {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			if err := writeJSON(w, r); err != nil {
+				pterm.Error.Println("Cannot write JSON response:", err)
+			}
+			return
+		}
+
+		sections := r.Sections
+		if len(errorsList) > 0 {
+			errorRows := [][]string{{"#", "Error"}}
+			for i, err := range errorsList {
+				errorRows = append(errorRows, []string{pterm.Sprint(i + 1), err.Error()})
+			}
+			sections = append(sections, reportSection{Title: "Errors", Data: errorRows})
+		}
+		chartHead, chartBody := barBodySnippet(r.Charts...)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, htmlPage(sections, chartHead, chartBody, serveNav(viewCfg.Namespace)))
+	}
+}
+
+// collect returns the cached cluster scoped to the namespace of the view, or collects that namespace
+func (s *server) collect(ctx context.Context, viewCfg *Config) (*kram.Cluster, []error) {
+	s.mu.RLock()
+	cached, cachedErrors := s.cached, s.cachedErrors
+	s.mu.RUnlock()
+	if cached != nil {
+		return scopeCluster(cached, viewCfg.Namespace), cachedErrors
+	}
+
+	c := newCollector(viewCfg, s.clientset, s.metrics)
+	namespaces, err := c.NamespaceNames(ctx, viewCfg.Namespace)
+	if err != nil {
+		return &kram.Cluster{}, []error{err}
+	}
+	return c.Collect(ctx, namespaces)
+}
+
+// scopeCluster keeps only the given namespace of the cluster, like a collection of that namespace would.
+// The cached cluster is shared between requests, so it is copied rather than modified.
+func scopeCluster(cluster *kram.Cluster, namespace string) *kram.Cluster {
+	if namespace == "" {
+		return cluster
+	}
+	scoped := *cluster
+	scoped.Namespaces = nil
+	if ns := cluster.Namespace(namespace); ns != nil {
+		scoped.Namespaces = []*kram.Namespace{ns}
+	}
+	return &scoped
+}
+
+// startRefresh collects every namespace with nodes now, then at every interval until ctx is done
+func (s *server) startRefresh(ctx context.Context, interval time.Duration) {
+	refreshCfg := *s.cfg
+	refreshCfg.Namespace = ""
+	refreshCfg.ShowNode = true
+	c := newCollector(&refreshCfg, s.clientset, s.metrics)
+
+	refresh := func() {
+		namespaces, err := c.NamespaceNames(ctx, "")
+		var cluster *kram.Cluster
+		var errorsList []error
+		if err != nil {
+			cluster, errorsList = &kram.Cluster{}, []error{err}
+		} else {
+			cluster, errorsList = c.Collect(ctx, namespaces)
+		}
+		s.mu.Lock()
+		s.cached, s.cachedErrors = cluster, errorsList
+		s.mu.Unlock()
+	}
+	refresh()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+}
+
+// serveNav links the views of kram serve, with the namespace views when one is selected
+func serveNav(namespace string) string {
+	nav := `<p><a href="/">Namespaces</a> · <a href="/nodes">Nodes</a>`
+	if namespace != "" {
+		path := html.EscapeString(url.PathEscape(namespace))
+		name := html.EscapeString(namespace)
+		nav += fmt.Sprintf(` · <a href="/ns/%s">%s</a> · <a href="/ns/%s/nodes">%s by node</a>`, path, name, path, name)
+	}
+	return nav + "</p>"
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/PaulPowershell/Kram/pkg/kram"
+)
+
+func TestServeCachedViews(t *testing.T) {
+	pod := func(namespace, name, node string) *kram.Pod {
+		return &kram.Pod{Name: name, Namespace: namespace, NodeName: node, Phase: "Running", HasMetrics: true, Containers: []*kram.Container{
+			{Name: "app", Kind: kram.ContainerKindApp, Quantities: kram.Quantities{Usage: kram.Resources{CPU: 100}, Request: kram.Resources{CPU: 200}}},
+		}}
+	}
+	cluster := &kram.Cluster{Namespaces: []*kram.Namespace{
+		{Name: "api", Pods: []*kram.Pod{pod("api", "api-1", "node-b")}},
+		{Name: "web", Pods: []*kram.Pod{pod("web", "web-a", "node-a")}},
+	}}
+
+	tests := []struct {
+		name       string
+		groupBy    string
+		path       string
+		wantStatus int
+		wantPods   []string
+		wantNodes  []string
+	}{
+		{name: "namespace", path: "/api/ns/web", wantStatus: http.StatusOK, wantPods: []string{"web-a"}},
+		{name: "namespace by node", path: "/api/ns/web/nodes", wantStatus: http.StatusOK, wantNodes: []string{"node-a"}},
+		{name: "missing namespace", path: "/api/ns/missing", wantStatus: http.StatusNotFound},
+		{name: "group-by on the namespace view", groupBy: "label:team", path: "/ns/web", wantStatus: http.StatusBadRequest},
+		{name: "group-by on the namespaces view", groupBy: "label:team", path: "/", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.GroupBy = tt.groupBy
+			s := &server{cfg: cfg, cached: cluster}
+
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantPods == nil && tt.wantNodes == nil {
+				return
+			}
+
+			var doc jsonDocument
+			if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+				t.Fatal(err)
+			}
+			var pods, nodes []string
+			for _, p := range doc.Pods {
+				pods = append(pods, p.Name)
+			}
+			for _, n := range doc.Nodes {
+				nodes = append(nodes, n.Name)
+			}
+			if tt.wantPods != nil && !reflect.DeepEqual(pods, tt.wantPods) {
+				t.Errorf("pods = %q, want %q", pods, tt.wantPods)
+			}
+			if tt.wantNodes != nil && !reflect.DeepEqual(nodes, tt.wantNodes) {
+				t.Errorf("nodes = %q, want %q", nodes, tt.wantNodes)
+			}
+		})
+	}
+}
+
+func TestScopeCluster(t *testing.T) {
+	api, web := &kram.Namespace{Name: "api"}, &kram.Namespace{Name: "web"}
+	cluster := &kram.Cluster{Namespaces: []*kram.Namespace{api, web}}
+
+	tests := []struct {
+		namespace string
+		want      []*kram.Namespace
+	}{
+		{namespace: "", want: []*kram.Namespace{api, web}},
+		{namespace: "web", want: []*kram.Namespace{web}},
+		{namespace: "missing"},
+	}
+
+	for _, tt := range tests {
+		if got := scopeCluster(cluster, tt.namespace).Namespaces; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("scopeCluster(%q) = %d namespaces, want %d", tt.namespace, len(got), len(tt.want))
+		}
+	}
+	if len(cluster.Namespaces) != 2 {
+		t.Errorf("scopeCluster modified the cached cluster: %d namespaces left", len(cluster.Namespaces))
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/PaulPowershell/Kram/pkg/kram"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================
+// SNAPSHOT (kram snapshot save <file>)
+// ============================================================
+
+func newSnapshotCmd(cfg *Config) *cobra.Command {
+	snapshotCmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Record cluster objects for offline analysis",
+	}
+
+	var includeNodes, includeOwners bool
+	saveCmd := &cobra.Command{
+		Use:   "save <file>",
+		Short: "Save namespaces, pods, nodes, their metrics and workload owners to a file readable with --from-snapshot",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			spinner, _ := pterm.DefaultSpinner.Start("Capturing snapshot")
+
+			if err := cfg.Validate(); err != nil {
+				spinner.Fail("Initialization error")
+				pterm.Error.Println(err)
+				os.Exit(1)
+			}
+
+			clientset, metricsClientset, err := connectClients(cfg)
+			if err != nil {
+				spinner.Fail("Initialization error")
+				pterm.Error.WithShowLineNumber(true).Println(err)
+				os.Exit(1)
+			}
+
+			c := kram.NewCollector(clientset, metricsClientset)
+			c.WrapCall = suppressKubernetesLogs
+			c.IncludeNodes = includeNodes
+			c.ResolveOwners = includeOwners
+			snap, err := c.Snapshot(context.TODO())
+			if err != nil {
+				spinner.Fail("Snapshot error")
+				pterm.Error.WithShowLineNumber(true).Println(err)
+				os.Exit(1)
+			}
+
+			if err := snap.WriteFile(args[0]); err != nil {
+				spinner.Fail("Snapshot error")
+				pterm.Error.Println("Cannot write snapshot file:", err)
+				os.Exit(1)
+			}
+
+			spinner.Success(pterm.Sprintf("Snapshot saved to %s (%d namespaces, %d pods, %d pod metrics, %d nodes, %d replicasets, %d jobs)",
+				args[0], len(snap.Namespaces), len(snap.Pods), len(snap.PodMetrics), len(snap.Nodes), len(snap.ReplicaSets), len(snap.Jobs)))
+		},
+	}
+	saveCmd.Flags().BoolVar(&includeNodes, "nodes", true, "Also capture nodes and node metrics (requires list on nodes)")
+	saveCmd.Flags().BoolVar(&includeOwners, "owners", true, "Also capture ReplicaSets and Jobs to resolve pods up to their Deployment or CronJob (requires list on them)")
+	snapshotCmd.AddCommand(saveCmd)
+
+	return snapshotCmd
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/PaulPowershell/Kram/pkg/kram"
+	"github.com/spf13/cobra"
+)
+
+// ============================================================
+// WASTE (kram waste [namespace])
+// ============================================================
+
+func newWasteCmd(cfg *Config) *cobra.Command {
+	var by, sortBy string
+	var top int
+
+	wasteCmd := &cobra.Command{
+		Use:   "waste [namespace]",
+		Short: "List the pods, workloads or namespaces wasting the most requested resources",
+		Long: "Waste ranks pods, workloads or namespaces by the part of their requests they do not use (request minus usage, " +
+			"container by container), with their usage/request efficiency.",
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.ResolveOwners = by == "workload"
+			cluster, errorsList := collectForCommand(cfg, args, false, func() error {
+				if (by != "pod" && by != "workload" && by != "namespace") || (sortBy != "cpu" && sortBy != "memory") || top < 0 {
+					return ErrInvalidWasteOptions
+				}
+				return nil
+			})
+
+			renderReport(cfg.OutputFormat, wasteReport(cluster, cfg.Namespace, by, sortBy, top), errorsList)
+
+			if !renderers[cfg.OutputFormat].embedsErrors {
+				printErrors(errorsList)
+			}
+		},
+	}
+
+	addCollectionFlags(wasteCmd, cfg, "Rank every namespace")
+	addSamplingFlags(wasteCmd, cfg, "Sample usage over this window and compare the requests with the average usage (e.g. 1h)")
+	wasteCmd.Flags().StringVar(&by, "by", "pod", "Rank pods, workloads or namespaces: pod, workload, namespace")
+	wasteCmd.Flags().StringVar(&sortBy, "sort-by", "cpu", "Rank by wasted cpu or memory")
+	wasteCmd.Flags().IntVar(&top, "top", 20, "Number of entries listed (0 lists all)")
+
+	return wasteCmd
+}
+
+// waster is one ranked entry of the waste report
+type waster struct {
+	namespace string
+	name      string
+	total     kram.Quantities
+	waste     kram.Resources
+}
+
+// wasters aggregates the pods of the cluster by pod, workload or namespace
+func wasters(cluster *kram.Cluster, by string) []waster {
+	var list []waster
+	index := make(map[string]int)
+	for _, ns := range cluster.Namespaces {
+		for _, pod := range ns.Pods {
+			name := pod.Name
+			switch by {
+			case "workload":
+				name = pod.Workload.String()
+			case "namespace":
+				name = ""
+			}
+			key := pod.Namespace + "/" + name
+			i, ok := index[key]
+			if !ok {
+				i = len(list)
+				index[key] = i
+				list = append(list, waster{namespace: pod.Namespace, name: name})
+			}
+			list[i].total.Add(pod.Total())
+			list[i].waste.Add(pod.Waste())
+		}
+	}
+	return list
+}
+
+func wasteReport(cluster *kram.Cluster, namespace string, by string, sortBy string, top int) *report {
+	list := wasters(cluster, by)
+
+	wasted := func(w waster) int64 {
+		if sortBy == "memory" {
+			return w.waste.Memory
+		}
+		return w.waste.CPU
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if wasted(a) != wasted(b) {
+			return wasted(a) > wasted(b)
+		}
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		return a.name < b.name
+	})
+
+	// The summary covers every entry, the table only the top ones
+	var totalQuantities kram.Quantities
+	var totalWaste kram.Resources
+	for _, w := range list {
+		totalQuantities.Add(w.total)
+		totalWaste.Add(w.waste)
+	}
+	if top > 0 && len(list) > top {
+		list = list[:top]
+	}
+
+	header := []string{"Namespace"}
+	if by != "namespace" {
+		header = append(header, strings.ToUpper(by[:1])+by[1:])
+	}
+	header = append(header, "CPU Request", "CPU Usage", "CPU Wasted", "CPU Usage/Req", "Mem Request", "Mem Usage", "Mem Wasted", "Mem Usage/Req")
+	tableData := [][]string{header}
+
+	doc := newJSONDocument("waste", namespace)
+	for _, w := range list {
+		row := []string{w.namespace}
+		if by != "namespace" {
+			row = append(row, w.name)
+		}
+		tableData = append(tableData, append(row,
+			orUnset(w.total.Set.CPURequest, formatCPU(w.total.Request.CPU)), formatCPU(w.total.Usage.CPU), formatCPU(w.waste.CPU), formatPercent(w.total.Usage.CPU, w.total.Request.CPU),
+			orUnset(w.total.Set.MemoryRequest, formatMemory(w.total.Request.Memory)), formatMemory(w.total.Usage.Memory), formatMemory(w.waste.Memory), formatPercent(w.total.Usage.Memory, w.total.Request.Memory)))
+
+		doc.Wasters = append(doc.Wasters, jsonWaster{
+			Namespace:     w.namespace,
+			Name:          w.name,
+			jsonResources: withJSONEfficiency(newJSONResources(w.total), w.total, w.waste, true),
+		})
+	}
+	doc.Total = withJSONEfficiency(newJSONResources(totalQuantities), totalQuantities, totalWaste, true)
+
+	summary := [][]string{
+		{"CPU Request", "CPU Wasted", "CPU Usage/Req", "Mem Request", "Mem Wasted", "Mem Usage/Req"},
+		{
+			orUnset(totalQuantities.Set.CPURequest, formatCPU(totalQuantities.Request.CPU)), formatCPU(totalWaste.CPU), formatPercent(totalQuantities.Usage.CPU, totalQuantities.Request.CPU),
+			orUnset(totalQuantities.Set.MemoryRequest, formatMemory(totalQuantities.Request.Memory)), formatMemory(totalWaste.Memory), formatPercent(totalQuantities.Usage.Memory, totalQuantities.Request.Memory),
+		},
+	}
+
+	name := "kram-waste"
+	if namespace != "" {
+		name = fmt.Sprintf("kram-waste-%s", namespace)
+	}
+	return &report{
+		Name: name,
+		Sections: []reportSection{
+			{Title: "Waste", Data: summary},
+			{Title: fmt.Sprintf("Top wasters by %s — %s", by, sortBy), Data: tableData},
+		},
+		Document: doc,
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaulPowershell/Kram/pkg/kram"
+	"github.com/pterm/pterm"
+	"k8s.io/client-go/kubernetes"
+	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// ============================================================
+// WATCH — dashboard redrawn in place (kram --watch=10s)
+// ============================================================
+
+// watch re-collects every cfg.Watch with the same clients and redraws the view in place until Ctrl+C
+func watch(cfg *Config, clientset kubernetes.Interface, metricsClientset metricsv.Interface) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	area, _ := pterm.DefaultArea.Start()
+	defer func() { _ = area.Stop() }()
+
+	c := newCollector(cfg, clientset, metricsClientset)
+	ticker := time.NewTicker(cfg.Watch)
+	defer ticker.Stop()
+
+	var previous map[string]string
+	for {
+		content, cells := watchFrame(ctx, cfg, c, previous)
+		area.Update(content)
+		previous = cells
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchFrame collects once and renders the dashboard, highlighting cells that changed since previous.
+// It returns the rendered frame and the plain cells to compare with at the next tick.
+func watchFrame(ctx context.Context, cfg *Config, c *kram.Collector, previous map[string]string) (string, map[string]string) {
+	var sb strings.Builder
+	sb.WriteString(pterm.Sprintf("%s every %s — %s — Ctrl+C to quit\n\n",
+		pterm.Bold.Sprint("kram --watch"), cfg.Watch, time.Now().Format("15:04:05")))
+
+	namespaces, err := c.NamespaceNames(ctx, cfg.Namespace)
+	if err != nil {
+		sb.WriteString(pterm.Error.Sprintln(err))
+		return sb.String(), previous
+	}
+	cluster, errorsList := c.Collect(ctx, namespaces)
+
+	_, r := buildReport(cfg, cluster)
+	if r == nil {
+		sb.WriteString(pterm.Warning.Sprintf("No pods found in namespace: %s\n", cfg.Namespace))
+		return sb.String(), previous
+	}
+
+	cells := make(map[string]string)
+	sections := make([]reportSection, len(r.Sections))
+	for i, section := range r.Sections {
+		sections[i] = reportSection{Title: section.Title, Data: make([][]string, len(section.Data)), Detail: section.Detail}
+		occurrences := make(map[string]int)
+		for j, row := range section.Data {
+			// Rows are matched by their first cell; pods repeat once per container
+			rowKey := ""
+			if len(row) > 0 {
+				rowKey = fmt.Sprintf("%s#%d", row[0], occurrences[row[0]])
+				occurrences[row[0]]++
+			}
+			sections[i].Data[j] = make([]string, len(row))
+			for k, cell := range row {
+				key := fmt.Sprintf("%s\x00%s\x00%d", section.Title, rowKey, k)
+				cells[key] = cell
+				sections[i].Data[j][k] = cell
+				if prev, ok := previous[key]; ok && j > 0 && k > 0 {
+					sections[i].Data[j][k] = watchCell(cell, prev)
+				}
+			}
+		}
+	}
+
+	tables, err := sprintSections(sections)
+	if err != nil {
+		sb.WriteString(pterm.Error.Sprintln(err))
+		return sb.String(), cells
+	}
+	sb.WriteString(tables)
+
+	for i, err := range errorsList {
+		if i == 0 {
+			sb.WriteString(pterm.Warning.Sprintln("Error(s):"))
+		}
+		sb.WriteString(fmt.Sprintf("%d. %v\n", i+1, err))
+	}
+	return sb.String(), cells
+}
+
+// watchCell highlights a changed cell: red when the value grew, green when it shrank,
+// with the delta when the cell holds a single quantity ("42 m", "164.0 MiB")
+func watchCell(cell, previous string) string {
+	if cell == previous {
+		return cell
+	}
+	value, unit, decimals, ok := parseQuantityCell(cell)
+	prevValue, prevUnit, _, prevOK := parseQuantityCell(previous)
+	if !ok || !prevOK || unit != prevUnit {
+		return pterm.FgYellow.Sprint(cell)
+	}
+
+	delta := value - prevValue
+	text := fmt.Sprintf("%s (%+.*f)", cell, decimals, delta)
+	if delta > 0 {
+		return pterm.FgLightRed.Sprint(text)
+	}
+	return pterm.FgLightGreen.Sprint(text)
+}
+
+// parseQuantityCell reads the cells written by formatCPU and formatMemory
+func parseQuantityCell(cell string) (value float64, unit string, decimals int, ok bool) {
+	fields := strings.Fields(cell)
+	if len(fields) != 2 {
+		return 0, "", 0, false
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, "", 0, false
+	}
+	if i := strings.IndexByte(fields[0], '.'); i >= 0 {
+		decimals = len(fields[0]) - i - 1
+	}
+	return value, fields[1], decimals, true
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/PaulPowershell/Kram/pkg/kram"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/pterm/pterm"
+)
+
+// ============================================================
+// WORKLOADS — vue par contrôleur (kram --workloads)
+// ============================================================
+
+// workloadsReport sums the pods of every workload of the namespace, or of the cluster when empty.
+// It returns nil when the namespace has no pods.
+func workloadsReport(cluster *kram.Cluster, namespace string, order kram.Order) *report {
+	var workloads []*kram.WorkloadPods
+	if namespace == "" {
+		workloads = cluster.Workloads()
+	} else if ns := cluster.Namespace(namespace); ns != nil {
+		workloads = ns.Workloads()
+	}
+	if len(workloads) == 0 {
+		return nil
+	}
+	workloads, rest := kram.Arrange(workloads, order, workloadName, (*kram.WorkloadPods).Total)
+
+	// The namespace column is only useful when several namespaces are listed
+	prefix := func(w *kram.WorkloadPods, cells ...string) []string {
+		if namespace != "" {
+			return cells
+		}
+		return append([]string{w.Namespace}, cells...)
+	}
+	header := func(cells ...string) []string {
+		if namespace != "" {
+			return append(cells, quantitiesHeader(false)...)
+		}
+		return append(append([]string{"Namespace"}, cells...), quantitiesHeader(false)...)
+	}
+
+	totalTableData := [][]string{header("Workload", "Replicas")}
+	replicaTableData := [][]string{header("Workload", "Replicas")}
+	podTableData := [][]string{header("Workload", "Pod", "Node")}
+
+	doc := newJSONDocument("workloads", namespace)
+	var total kram.Quantities
+	var pods int
+	var xLabels []string
+	var values []kram.Quantities
+
+	for _, w := range workloads {
+		q := w.Total()
+		perReplica := w.PerReplica()
+		replicas := pterm.Sprint(len(w.Pods))
+		totalTableData = append(totalTableData, append(prefix(w, w.Workload.String(), replicas), formatQuantities(q)...))
+		replicaTableData = append(replicaTableData, append(prefix(w, w.Workload.String(), replicas), formatQuantities(perReplica)...))
+
+		entry := jsonWorkload{
+			Namespace:     w.Namespace,
+			Kind:          w.Workload.Kind,
+			Name:          w.Workload.Name,
+			Replicas:      len(w.Pods),
+			jsonResources: newJSONResources(q),
+			PerReplica:    newJSONResources(perReplica),
+		}
+		for _, pod := range w.Pods {
+			podTableData = append(podTableData, append(prefix(w, w.Workload.String(), pod.Name, pod.ScheduledOn()), formatQuantities(pod.Total())...))
+			entry.Pods = append(entry.Pods, jsonPod{Name: pod.Name, Namespace: pod.Namespace, Node: pod.NodeName, jsonResources: newJSONResources(pod.Total())})
+		}
+		doc.Workloads = append(doc.Workloads, entry)
+
+		total.Add(q)
+		pods += len(w.Pods)
+		label := w.Workload.String()
+		if namespace == "" {
+			label = w.Namespace + "/" + label
+		}
+		xLabels = append(xLabels, label)
+		values = append(values, q)
+	}
+
+	// The workloads past --top share a single row of the totals table
+	if len(rest) > 0 {
+		var others kram.Quantities
+		var othersPods int
+		for _, w := range rest {
+			others.Add(w.Total())
+			othersPods += len(w.Pods)
+		}
+		othersRow := []string{othersName(len(rest)), pterm.Sprint(othersPods)}
+		if namespace == "" {
+			othersRow = []string{othersName(len(rest)), "", pterm.Sprint(othersPods)}
+		}
+		totalTableData = append(totalTableData, append(othersRow, formatQuantities(others)...))
+		total.Add(others)
+		pods += othersPods
+	}
+
+	totalRow := []string{"Total", pterm.Sprint(pods)}
+	podTotalRow := []string{"Total", "", ""}
+	if namespace == "" {
+		totalRow = []string{"Total", "", pterm.Sprint(pods)}
+		podTotalRow = append(podTotalRow, "")
+	}
+	totalTableData = append(totalTableData, append(totalRow, formatQuantities(total)...))
+	podTableData = append(podTableData, append(podTotalRow, formatQuantities(total)...))
+	doc.Total = newJSONResources(total)
+
+	scope := "all namespaces"
+	name := "kram-workloads"
+	if namespace != "" {
+		scope = namespace
+		name = fmt.Sprintf("kram-workloads-%s", namespace)
+	}
+
+	cpuBarChart, memBarChart := quantitiesBarCharts(xLabels, values,
+		"CPU — Usage / Request / Limit — Workloads",
+		"Memory — Usage / Request / Limit — Workloads")
+
+	return &report{
+		Name: name,
+		Sections: []reportSection{
+			{Title: fmt.Sprintf("Workloads — %s", scope), Data: totalTableData},
+			{Title: "Per replica — average of the replicas reported by metrics-server", Data: replicaTableData},
+			{Title: "Pods per workload", Data: podTableData, Detail: true},
+		},
+		Charts:   []*charts.Bar{cpuBarChart, memBarChart},
+		Document: doc,
+	}
+}
+
+func workloadName(w *kram.WorkloadPods) string { return w.Namespace + "/" + w.Workload.String() }